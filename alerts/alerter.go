@@ -0,0 +1,299 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/alis93/hn-scraper/metrics"
+)
+
+const (
+	// Header holding the HMAC-SHA256 of the body, as sha256=<hex>
+	SIGNATURE_HEADER = "X-HN-Scraper-Signature"
+	USER_AGENT       = "hn-scraper alerts (+https://github.com/alis93/hn-scraper)"
+)
+
+// The body posted to webhooks when a story matches one or more rules
+type Alert struct {
+	Rules []string          `json:"rules"`
+	List  string            `json:"list,omitempty"`
+	Story *hackernews.Story `json:"story"`
+	Time  time.Time         `json:"time"`
+}
+
+// Evaluates rules against stories and posts alerts to webhooks.
+// Each story is only sent to each webhook once, even if it matches again later.
+type Alerter struct {
+	rules     []compiledRule
+	webhooks  []Webhook
+	http      *http.Client
+	retry     hackernews.RetryPolicy
+	stateFile string
+	metrics   *AlertMetrics
+	logger    *slog.Logger
+
+	mu   sync.Mutex
+	sent map[string]bool
+}
+
+// Metrics recorded by an Alerter
+type AlertMetrics struct {
+	alerts *metrics.CounterVec
+}
+
+// Optional settings applied when creating an alerter
+type AlerterOption func(*Alerter)
+
+// Sets how failed deliveries are retried. By default they are not retried.
+func WithRetries(policy hackernews.RetryPolicy) AlerterOption {
+	return func(a *Alerter) {
+		a.retry = policy
+	}
+}
+
+// Sets the timeout of each delivery attempt. Defaults to 10 seconds.
+func WithTimeout(timeout time.Duration) AlerterOption {
+	return func(a *Alerter) {
+		a.http.Timeout = timeout
+	}
+}
+
+// Saves which stories have been sent to path, so alerts are not repeated after a restart.
+func WithStateFile(path string) AlerterOption {
+	return func(a *Alerter) {
+		a.stateFile = path
+	}
+}
+
+// Records sent and failed alerts in m.
+func WithMetrics(m *AlertMetrics) AlerterOption {
+	return func(a *Alerter) {
+		a.metrics = m
+	}
+}
+
+// Logs sent alerts at info level and failures at error level to logger.
+// By default nothing is logged.
+func WithLogger(logger *slog.Logger) AlerterOption {
+	return func(a *Alerter) {
+		a.logger = logger
+	}
+}
+
+// Registers the alert metrics in registry
+func NewAlertMetrics(registry *metrics.Registry) *AlertMetrics {
+	return &AlertMetrics{
+		alerts: registry.NewCounterVec("hn_alerts_total",
+			"Alerts posted to webhooks by webhook and result.", "webhook", "result"),
+	}
+}
+
+// Safe to call on nil metrics.
+func (m *AlertMetrics) observe(webhook, result string) {
+	if m == nil {
+		return
+	}
+	m.alerts.Inc(webhook, result)
+}
+
+// Creates an alerter for the rules and webhooks in cfg.
+// Returns error if cfg is invalid or the state file cannot be read
+func NewAlerter(cfg Config, opts ...AlerterOption) (*Alerter, error) {
+	rules, err := cfg.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	alerter := &Alerter{
+		rules:    rules,
+		webhooks: cfg.Webhooks,
+		http:     &http.Client{Timeout: 10 * time.Second},
+		logger:   slog.New(slog.DiscardHandler),
+		sent:     make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(alerter)
+	}
+
+	if err := alerter.loadState(); err != nil {
+		return nil, err
+	}
+	return alerter, nil
+}
+
+// Evaluates every rule against the stories of list and posts an alert for each match
+// to webhooks that have not been sent the story before.
+// Returns the alerts that were delivered, and a *DeliveryErr for each that failed, joined together.
+// Failed alerts are tried again the next time Check sees the story
+func (a *Alerter) Check(ctx context.Context, list string, stories []*hackernews.Story) ([]Alert, error) {
+	delivered := []Alert{}
+	var errs []error
+
+	for _, story := range stories {
+		if story == nil {
+			continue
+		}
+		for _, webhook := range a.webhooks {
+			matched := a.match(story, webhook.Name)
+			if len(matched) == 0 || a.wasSent(webhook.Name, story.ID) {
+				continue
+			}
+
+			alert := Alert{Rules: matched, List: list, Story: story, Time: time.Now().UTC()}
+			if err := a.deliver(ctx, webhook, alert); err != nil {
+				a.metrics.observe(webhook.Name, "error")
+				a.logger.Error("unable to send alert", "webhook", webhook.Name, "story_id", story.ID, "error", err)
+				errs = append(errs, &DeliveryErr{webhook.Name, story.ID, err})
+				continue
+			}
+
+			a.metrics.observe(webhook.Name, "sent")
+			a.logger.Info("alert sent", "webhook", webhook.Name, "story_id", story.ID, "rules", matched)
+			a.markSent(webhook.Name, story.ID)
+			delivered = append(delivered, alert)
+		}
+	}
+
+	if len(delivered) > 0 {
+		if err := a.saveState(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return delivered, errors.Join(errs...)
+}
+
+// Returns the names of the rules sending to webhook that the story matches
+func (a *Alerter) match(story *hackernews.Story, webhook string) []string {
+	matched := []string{}
+	for _, rule := range a.rules {
+		if rule.sendsTo(webhook) && rule.matches(story) {
+			matched = append(matched, rule.Name)
+		}
+	}
+	return matched
+}
+
+// Posts the alert as json to the webhook, signed with its secret, retrying according to the retry policy
+func (a *Alerter) deliver(ctx context.Context, webhook Webhook, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", USER_AGENT)
+	if webhook.Secret != "" {
+		req.Header.Set(SIGNATURE_HEADER, Sign(webhook.Secret, body))
+	}
+
+	res, err := a.retry.Do(a.http, req, func(res *http.Response, err error) {
+		if err == nil {
+			err = &StatusCodeErr{webhook.URL, res.StatusCode}
+		}
+		a.logger.Warn("retrying alert", "webhook", webhook.Name, "story_id", alert.Story.ID, "error", err)
+	})
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return &StatusCodeErr{webhook.URL, res.StatusCode}
+	}
+	return nil
+}
+
+// Returns the signature of body sent in SIGNATURE_HEADER
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Returns true if signature is the signature of body. For use by webhook receivers
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+func sentKey(webhook string, storyID int) string {
+	return fmt.Sprintf("%s/%d", webhook, storyID)
+}
+
+func (a *Alerter) wasSent(webhook string, storyID int) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.sent[sentKey(webhook, storyID)]
+}
+
+func (a *Alerter) markSent(webhook string, storyID int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sent[sentKey(webhook, storyID)] = true
+}
+
+// Reads the sent alerts from the state file, if there is one
+func (a *Alerter) loadState() error {
+	if a.stateFile == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(a.stateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	keys := []string{}
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		a.sent[key] = true
+	}
+	return nil
+}
+
+// Writes the sent alerts to the state file, if there is one.
+// Writes to a temporary file first and renames it so a crash never leaves a half written file
+func (a *Alerter) saveState() error {
+	if a.stateFile == "" {
+		return nil
+	}
+
+	a.mu.Lock()
+	keys := make([]string, 0, len(a.sent))
+	for key := range a.sent {
+		keys = append(keys, key)
+	}
+	a.mu.Unlock()
+	sort.Strings(keys)
+
+	data, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	tmp := a.stateFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, a.stateFile)
+}
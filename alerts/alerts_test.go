@@ -0,0 +1,224 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/alis93/hn-scraper/metrics"
+	"github.com/google/go-cmp/cmp"
+)
+
+// Records the alerts posted to it, failing the first failures requests
+type receiver struct {
+	secret   string
+	failures int
+
+	mu       sync.Mutex
+	requests int
+	alerts   []Alert
+	badSigs  int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests++
+	if r.requests <= r.failures {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	if r.secret != "" && !Verify(r.secret, body, req.Header.Get(SIGNATURE_HEADER)) {
+		r.badSigs++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	alert := Alert{}
+	json.Unmarshal(body, &alert)
+	r.alerts = append(r.alerts, alert)
+}
+
+func (r *receiver) storyIds() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := []int{}
+	for _, alert := range r.alerts {
+		ids = append(ids, alert.Story.ID)
+	}
+	return ids
+}
+
+var testStories = []*hackernews.Story{
+	{ID: 1, Title: "Show HN: Acme, a faster database", URL: "https://acme.io/launch", Author: "founder", Points: 150},
+	{ID: 2, Title: "Competitor raises Series B", URL: "https://blog.rival.com/series-b", Author: "someone", Points: 40},
+	{ID: 3, Title: "Unrelated story", URL: "https://example.com/", Author: "someone", Points: 500},
+	{ID: 4, Title: "acme in the news", URL: "https://news.example.com/", Author: "reporter", Points: 5},
+	nil,
+}
+
+func TestAlerterCheck(t *testing.T) {
+	log.Println("Testing alerts are matched, signed and delivered once")
+
+	product := &receiver{secret: "s3cret", failures: 1}
+	competitors := &receiver{}
+	productServer := httptest.NewServer(product)
+	defer productServer.Close()
+	competitorServer := httptest.NewServer(competitors)
+	defer competitorServer.Close()
+
+	cfg := Config{
+		Rules: []Rule{
+			{Name: "product", Title: "(?i)acme", MinPoints: 10, Webhooks: []string{"product"}},
+			{Name: "founder", Author: "FOUNDER", Webhooks: []string{"product"}},
+			{Name: "rival", Domain: "rival.com"},
+		},
+		Webhooks: []Webhook{
+			{Name: "product", URL: productServer.URL, Secret: "s3cret"},
+			{Name: "competitors", URL: competitorServer.URL},
+		},
+	}
+
+	registry := metrics.NewRegistry()
+	m := NewAlertMetrics(registry)
+	alerter, err := NewAlerter(cfg, WithMetrics(m), WithRetries(hackernews.RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	delivered, err := alerter.Check(context.Background(), "top", testStories)
+	if err != nil {
+		t.Fatalf("Check failed. Reason : %s", err.Error())
+	}
+	if len(delivered) != 3 {
+		t.Errorf("Expected 3 alerts but got %d", len(delivered))
+	}
+
+	if ids := product.storyIds(); !cmp.Equal(ids, []int{1, 2}) {
+		t.Errorf("Product webhook alerts incorrect. \n\t Expected %v Actual %v", []int{1, 2}, ids)
+	}
+	if ids := competitors.storyIds(); !cmp.Equal(ids, []int{2}) {
+		t.Errorf("Competitor webhook alerts incorrect. \n\t Expected %v Actual %v", []int{2}, ids)
+	}
+	if rules := product.alerts[0].Rules; !cmp.Equal(rules, []string{"product", "founder"}) {
+		t.Errorf("Matched rules incorrect. Actual %v", rules)
+	}
+	if product.alerts[0].List != "top" || product.badSigs != 0 {
+		t.Errorf("Alert list or signature incorrect. %+v", product.alerts[0])
+	}
+
+	// the same stories do not alert again
+	delivered, _ = alerter.Check(context.Background(), "top", testStories)
+	if len(delivered) != 0 || len(product.storyIds()) != 2 {
+		t.Errorf("Expected no repeated alerts but got %d", len(delivered))
+	}
+
+	if v := m.alerts.Value("product", "sent"); v != 2 {
+		t.Errorf("Sent alerts metric incorrect. \n\t Expected %d Actual %v", 2, v)
+	}
+}
+
+func TestAlerterDeliveryFailure(t *testing.T) {
+	failing := &receiver{failures: 2}
+	server := httptest.NewServer(failing)
+	defer server.Close()
+
+	cfg := Config{
+		Rules:    []Rule{{Name: "popular", MinPoints: 100}},
+		Webhooks: []Webhook{{Name: "hook", URL: server.URL}},
+	}
+	alerter, _ := NewAlerter(cfg)
+
+	_, err := alerter.Check(context.Background(), "top", testStories)
+	var deliveryErr *DeliveryErr
+	if !errors.As(err, &deliveryErr) {
+		t.Fatalf("Expected *DeliveryErr but got %v", err)
+	}
+
+	// failed alerts are tried again
+	alerter.Check(context.Background(), "top", testStories)
+	delivered, err := alerter.Check(context.Background(), "top", testStories)
+	if err != nil || len(failing.storyIds()) != 2 || len(delivered) != 0 {
+		t.Errorf("Expected both stories to be delivered once the webhook recovered. %v %v", failing.storyIds(), err)
+	}
+}
+
+func TestAlerterStateFile(t *testing.T) {
+	hook := &receiver{}
+	server := httptest.NewServer(hook)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "alerts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	state := filepath.Join(dir, "sent.json")
+
+	cfg := Config{
+		Rules:    []Rule{{Name: "popular", MinPoints: 100}},
+		Webhooks: []Webhook{{Name: "hook", URL: server.URL}},
+	}
+	alerter, _ := NewAlerter(cfg, WithStateFile(state))
+	alerter.Check(context.Background(), "top", testStories)
+
+	// a restarted alerter remembers what was sent
+	restarted, err := NewAlerter(cfg, WithStateFile(state))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivered, _ := restarted.Check(context.Background(), "top", testStories); len(delivered) != 0 {
+		t.Errorf("Expected no alerts after a restart but got %d", len(delivered))
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	hook := Webhook{Name: "hook", URL: "https://hooks.example.com/alerts"}
+
+	validateTests := []struct {
+		name     string
+		cfg      Config
+		expected interface{}
+	}{
+		{"valid", Config{Rules: []Rule{{Name: "r", Author: "pg"}}, Webhooks: []Webhook{hook}}, nil},
+		{"no rules", Config{Webhooks: []Webhook{hook}}, NoRulesErr},
+		{"no webhooks", Config{Rules: []Rule{{Name: "r", Author: "pg"}}}, NoWebhooksErr},
+		{"empty rule", Config{Rules: []Rule{{Name: "r"}}, Webhooks: []Webhook{hook}}, &EmptyRuleErr{}},
+		{"bad pattern", Config{Rules: []Rule{{Name: "r", Title: "(unclosed"}}, Webhooks: []Webhook{hook}}, &InvalidPatternErr{}},
+		{"unknown webhook", Config{Rules: []Rule{{Name: "r", Author: "pg", Webhooks: []string{"other"}}}, Webhooks: []Webhook{hook}}, &UnknownWebhookErr{}},
+		{"bad url", Config{Rules: []Rule{{Name: "r", Author: "pg"}}, Webhooks: []Webhook{{Name: "hook", URL: "ftp://example.com"}}}, &InvalidWebhookErr{}},
+		{"duplicate webhook", Config{Rules: []Rule{{Name: "r", Author: "pg"}}, Webhooks: []Webhook{hook, hook}}, &InvalidWebhookErr{}},
+	}
+
+	for _, test := range validateTests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.cfg.Validate()
+			switch expected := test.expected.(type) {
+			case nil:
+				if err != nil {
+					t.Errorf("Expected valid config but got %v", err)
+				}
+			case error:
+				if err == nil || (err != expected && reflectType(err) != reflectType(expected)) {
+					t.Errorf("Expected %T but got %v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+func reflectType(v interface{}) string {
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,70 @@
+package alerts
+
+import "fmt"
+
+var (
+	NoWebhooksErr = fmt.Errorf("Alerts need at least one webhook")
+	NoRulesErr    = fmt.Errorf("Alerts need at least one rule")
+)
+
+type EmptyRuleErr struct {
+	rule string
+}
+
+func (e *EmptyRuleErr) Error() string {
+	return fmt.Sprintf("Rule %q has no conditions. Set at least one of title, domain, author or minPoints", e.rule)
+}
+
+type InvalidPatternErr struct {
+	rule string
+	err  error
+}
+
+func (e *InvalidPatternErr) Error() string {
+	return fmt.Sprintf("Rule %q has an invalid title pattern. \t %s", e.rule, e.err.Error())
+}
+
+func (e *InvalidPatternErr) Unwrap() error {
+	return e.err
+}
+
+type UnknownWebhookErr struct {
+	rule    string
+	webhook string
+}
+
+func (e *UnknownWebhookErr) Error() string {
+	return fmt.Sprintf("Rule %q sends to webhook %q which is not configured", e.rule, e.webhook)
+}
+
+type InvalidWebhookErr struct {
+	webhook string
+	reason  string
+}
+
+func (e *InvalidWebhookErr) Error() string {
+	return fmt.Sprintf("Webhook %q is invalid, %s", e.webhook, e.reason)
+}
+
+type DeliveryErr struct {
+	webhook string
+	storyID int
+	err     error
+}
+
+func (e *DeliveryErr) Error() string {
+	return fmt.Sprintf("Failed to send alert for story %d to webhook %q. \t %s", e.storyID, e.webhook, e.err.Error())
+}
+
+func (e *DeliveryErr) Unwrap() error {
+	return e.err
+}
+
+type StatusCodeErr struct {
+	url        string
+	statusCode int
+}
+
+func (e *StatusCodeErr) Error() string {
+	return fmt.Sprintf("Request to %s failed with status code %d", e.url, e.statusCode)
+}
@@ -0,0 +1,132 @@
+package alerts
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+// Alert rules and where to send the alerts
+type Config struct {
+	Rules    []Rule    `json:"rules" yaml:"rules" toml:"rules"`
+	Webhooks []Webhook `json:"webhooks" yaml:"webhooks" toml:"webhooks"`
+}
+
+// Conditions a story must meet to raise an alert. Every condition that is set must match
+type Rule struct {
+	Name string `json:"name" yaml:"name" toml:"name"`
+	// Regular expression matched against the title. Use (?i) to ignore case
+	Title string `json:"title" yaml:"title" toml:"title"`
+	// Matches stories whose url host is this domain or one of its subdomains
+	Domain string `json:"domain" yaml:"domain" toml:"domain"`
+	// Matches stories submitted by this user, ignoring case
+	Author    string `json:"author" yaml:"author" toml:"author"`
+	MinPoints int    `json:"minPoints" yaml:"minPoints" toml:"minPoints"`
+	// Names of the webhooks to alert. Every webhook if empty
+	Webhooks []string `json:"webhooks" yaml:"webhooks" toml:"webhooks"`
+}
+
+// Where alerts are posted
+type Webhook struct {
+	Name string `json:"name" yaml:"name" toml:"name"`
+	URL  string `json:"url" yaml:"url" toml:"url"`
+	// Key used to sign each alert. Alerts are not signed if empty
+	Secret string `json:"secret" yaml:"secret" toml:"secret"`
+}
+
+// A rule ready to be matched against stories
+type compiledRule struct {
+	Rule
+	title *regexp.Regexp
+}
+
+// Returns error if a rule is empty, has an invalid pattern or names a webhook that does not exist,
+// or a webhook does not have an http or https url
+func (cfg Config) Validate() error {
+	_, err := cfg.compile()
+	return err
+}
+
+func (cfg Config) compile() ([]compiledRule, error) {
+	if len(cfg.Rules) == 0 {
+		return nil, NoRulesErr
+	}
+	if len(cfg.Webhooks) == 0 {
+		return nil, NoWebhooksErr
+	}
+
+	names := make(map[string]bool)
+	for _, webhook := range cfg.Webhooks {
+		if webhook.Name == "" {
+			return nil, &InvalidWebhookErr{webhook.URL, "it has no name"}
+		}
+		if names[webhook.Name] {
+			return nil, &InvalidWebhookErr{webhook.Name, "the name is used twice"}
+		}
+		if err := hackernews.DefaultURLPolicy.Validate(webhook.URL); err != nil {
+			return nil, &InvalidWebhookErr{webhook.Name, err.Error()}
+		}
+		names[webhook.Name] = true
+	}
+
+	rules := make([]compiledRule, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		if rule.Title == "" && rule.Domain == "" && rule.Author == "" && rule.MinPoints <= 0 {
+			return nil, &EmptyRuleErr{rule.Name}
+		}
+		for _, webhook := range rule.Webhooks {
+			if !names[webhook] {
+				return nil, &UnknownWebhookErr{rule.Name, webhook}
+			}
+		}
+		rules[i].Rule = rule
+		if rule.Title != "" {
+			pattern, err := regexp.Compile(rule.Title)
+			if err != nil {
+				return nil, &InvalidPatternErr{rule.Name, err}
+			}
+			rules[i].title = pattern
+		}
+	}
+	return rules, nil
+}
+
+// Returns true if the story meets every condition of the rule
+func (r compiledRule) matches(story *hackernews.Story) bool {
+	if r.title != nil && !r.title.MatchString(story.Title) {
+		return false
+	}
+	if r.Domain != "" && !hasDomain(story.URL, r.Domain) {
+		return false
+	}
+	if r.Author != "" && !strings.EqualFold(story.Author, r.Author) {
+		return false
+	}
+	return story.Points >= r.MinPoints
+}
+
+// Returns true if the webhook should receive alerts from the rule
+func (r compiledRule) sendsTo(webhook string) bool {
+	if len(r.Webhooks) == 0 {
+		return true
+	}
+	for _, name := range r.Webhooks {
+		if name == webhook {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if the host of rawURL is domain or a subdomain of it
+func hasDomain(rawURL, domain string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
@@ -0,0 +1,153 @@
+package algolia
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+const (
+	BASE_URL                = "https://hn.algolia.com/api/v1"
+	SEARCH_ENDPOINT         = "search"
+	SEARCH_BY_DATE_ENDPOINT = "search_by_date"
+)
+
+// Searches stories and comments with the hn.algolia.com api.
+// search ranks hits by relevance and search_by_date by newest first
+type Client struct {
+	http    *http.Client
+	baseURL string
+	retry   hackernews.RetryPolicy
+	logger  *slog.Logger
+}
+
+// Optional settings applied when creating a client
+type ClientOption func(*Client)
+
+// Sets the base url of the api, for example to point the client at a test server.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// Sets how failed requests are retried. By default requests are not retried.
+func WithRetries(policy hackernews.RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// Sends requests with rt instead of http.DefaultTransport, for example to record or replay them.
+func WithTransport(rt http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.http.Transport = rt
+	}
+}
+
+// Logs requests at debug level and retries at warn level to logger.
+// By default nothing is logged.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// Creates a client with given timeout in seconds
+func NewClient(timeout int, opts ...ClientOption) (*Client, error) {
+	if timeout <= 0 {
+		return nil, InvalidTimeOutErr
+	}
+
+	client := &Client{
+		http:    &http.Client{Timeout: time.Duration(timeout) * time.Second},
+		baseURL: BASE_URL,
+		logger:  slog.New(slog.DiscardHandler),
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client, nil
+}
+
+// Returns a page of hits for the query, most relevant first
+func (c *Client) Search(ctx context.Context, q Query) (*Response, error) {
+	return c.search(ctx, SEARCH_ENDPOINT, q)
+}
+
+// Returns a page of hits for the query, newest first
+func (c *Client) SearchByDate(ctx context.Context, q Query) (*Response, error) {
+	return c.search(ctx, SEARCH_BY_DATE_ENDPOINT, q)
+}
+
+// Returns up to max hits for the query, requesting pages from q.Page on until there are enough or none are left.
+// byDate chooses search_by_date over search
+func (c *Client) SearchAll(ctx context.Context, q Query, byDate bool, max int) ([]Hit, error) {
+	endpoint := SEARCH_ENDPOINT
+	if byDate {
+		endpoint = SEARCH_BY_DATE_ENDPOINT
+	}
+
+	hits := []Hit{}
+	for len(hits) < max {
+		res, err := c.search(ctx, endpoint, q)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, res.Hits...)
+		q.Page++
+		if len(res.Hits) == 0 || q.Page >= res.NbPages {
+			break
+		}
+	}
+	if len(hits) > max {
+		hits = hits[:max]
+	}
+	return hits, nil
+}
+
+func (c *Client) search(ctx context.Context, endpoint string, q Query) (*Response, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	searchURL := fmt.Sprintf("%s/%s?%s", c.baseURL, endpoint, q.values().Encode())
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	res, err := c.retry.Do(c.http, req, func(res *http.Response, err error) {
+		if err == nil {
+			err = &StatusCodeErr{searchURL, res.StatusCode}
+		}
+		c.logger.Warn("retrying search", "url", searchURL, "error", err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	c.logger.Debug("search request", "url", searchURL, "status", res.StatusCode, "duration", time.Since(start))
+
+	if res.StatusCode != http.StatusOK {
+		return nil, &StatusCodeErr{searchURL, res.StatusCode}
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &Response{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
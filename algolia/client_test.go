@@ -0,0 +1,231 @@
+package algolia
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/google/go-cmp/cmp"
+)
+
+func helperLoadBytes(t *testing.T, name string) []byte {
+	bytes, err := ioutil.ReadFile(filepath.Join("./testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes
+}
+
+// A stub of the api that records the query strings it receives.
+// The first request fails so retries are tested
+func helperStubServer(t *testing.T, queries *[]string) *httptest.Server {
+	var mu sync.Mutex
+	failed := false
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !failed {
+			failed = true
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		*queries = append(*queries, r.URL.Path+"?"+r.URL.RawQuery)
+
+		values := r.URL.Query()
+		switch {
+		case values.Get("tags") == "comment,story_1001,author_rsc":
+			w.Write(helperLoadBytes(t, "comments.json"))
+		case values.Get("query") == "go" && values.Get("page") == "1":
+			w.Write(helperLoadBytes(t, "search_page1.json"))
+		case values.Get("query") == "go":
+			w.Write(helperLoadBytes(t, "search_page0.json"))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+}
+
+func helperClient(t *testing.T, server *httptest.Server) *Client {
+	client, err := NewClient(5, WithBaseURL(server.URL+"/api/v1"),
+		WithRetries(hackernews.RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestSearch(t *testing.T) {
+	log.Println("Testing algolia search")
+
+	queries := []string{}
+	server := helperStubServer(t, &queries)
+	defer server.Close()
+	client := helperClient(t, server)
+
+	res, err := client.Search(context.Background(), Query{
+		Text:        "go",
+		Tags:        []string{TAG_STORY, AnyTag(TAG_ASK_HN, TAG_FRONT_PAGE)},
+		Filters:     []NumericFilter{{FIELD_POINTS, ">=", 100}, {FIELD_CREATED_AT, ">", 1723000000}},
+		HitsPerPage: 2,
+	})
+	if err != nil {
+		t.Fatalf("Failed to search. Reason : %s", err.Error())
+	}
+
+	expectedQuery := "/api/v1/search?hitsPerPage=2&numericFilters=points%3E%3D100%2Ccreated_at_i%3E1723000000&query=go&tags=story%2C%28ask_hn%2Cfront_page%29"
+	if len(queries) != 1 || queries[0] != expectedQuery {
+		t.Errorf("Query incorrect. \n\t Expected %s \n\t Actual %v", expectedQuery, queries)
+	}
+	if res.NbHits != 3 || res.NbPages != 2 || len(res.Hits) != 2 {
+		t.Errorf("Response incorrect. %+v", res)
+	}
+
+	points, comments, storyID := 250, 42, 1001
+	expected := Hit{
+		ObjectID:    "1001",
+		Tags:        []string{"story", "author_pg", "story_1001", "front_page"},
+		Title:       "The Go Programming Language",
+		URL:         "https://go.dev/",
+		Author:      "pg",
+		Points:      &points,
+		NumComments: &comments,
+		StoryID:     &storyID,
+		CreatedAt:   1723456800,
+	}
+	if !cmp.Equal(res.Hits[0], expected) {
+		t.Errorf("Hit incorrect. \n%s", cmp.Diff(expected, res.Hits[0]))
+	}
+}
+
+func TestSearchByDateComments(t *testing.T) {
+	log.Println("Testing algolia comment search")
+
+	queries := []string{}
+	server := helperStubServer(t, &queries)
+	defer server.Close()
+	client := helperClient(t, server)
+
+	res, err := client.SearchByDate(context.Background(), Query{Tags: []string{TAG_COMMENT, StoryTag(1001), AuthorTag("rsc")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 1 || queries[0] != "/api/v1/search_by_date?query=&tags=comment%2Cstory_1001%2Cauthor_rsc" {
+		t.Errorf("Query incorrect. %v", queries)
+	}
+
+	item, err := res.Hits[0].RawItem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &hackernews.RawItem{ID: 1010, ItemType: "comment", By: "rsc", Timestamp: 1723460400, Parent: 1001,
+		Text: "Simplicity is <i>complicated</i>."}
+	if !cmp.Equal(item, expected) {
+		t.Errorf("Comment incorrect. \n%s", cmp.Diff(expected, item))
+	}
+}
+
+func TestSearchAll(t *testing.T) {
+	log.Println("Testing algolia pagination")
+
+	queries := []string{}
+	server := helperStubServer(t, &queries)
+	defer server.Close()
+	client := helperClient(t, server)
+
+	hits, err := client.SearchAll(context.Background(), Query{Text: "go", HitsPerPage: 2}, false, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 3 || hits[2].ObjectID != "1003" || len(queries) != 2 {
+		t.Errorf("Expected 3 hits from 2 pages but got %d from %v", len(hits), queries)
+	}
+
+	queries = queries[:0]
+	hits, _ = client.SearchAll(context.Background(), Query{Text: "go", HitsPerPage: 2}, false, 1)
+	if len(hits) != 1 || len(queries) != 1 {
+		t.Errorf("Expected 1 hit from 1 page but got %d from %v", len(hits), queries)
+	}
+}
+
+func TestStories(t *testing.T) {
+	log.Println("Testing converting hits to stories")
+
+	queries := []string{}
+	server := helperStubServer(t, &queries)
+	defer server.Close()
+	client := helperClient(t, server)
+	converter, err := hackernews.NewItemConverter(hackernews.DefaultConverterConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the ask hn story has no url, so is rejected as it would be from the hackernews api
+	res, _ := client.Search(context.Background(), Query{Text: "go", HitsPerPage: 2})
+	stories, err := res.Stories(converter)
+	if err == nil {
+		t.Errorf("Expected the story without a url to be rejected")
+	}
+	if len(stories) != 1 || stories[0].Domain != "go.dev" || stories[0].Points != 250 || stories[0].Comments != 42 || stories[0].Rank != 1 {
+		t.Fatalf("Stories incorrect. %v", stories)
+	}
+
+	// ranks continue from the first page
+	res, _ = client.Search(context.Background(), Query{Text: "go", HitsPerPage: 2, Page: 1})
+	stories, err = res.Stories(converter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stories) != 1 || stories[0].Rank != 3 {
+		t.Errorf("Expected one story with rank 3 but got %v", stories)
+	}
+}
+
+func TestInvalidQueries(t *testing.T) {
+	log.Println("Testing invalid queries")
+
+	client, _ := NewClient(5, WithBaseURL("http://127.0.0.1:0"))
+	tests := []struct {
+		name  string
+		query Query
+	}{
+		{"field", Query{Filters: []NumericFilter{{"score", ">", 1}}}},
+		{"operator", Query{Filters: []NumericFilter{{FIELD_POINTS, "!=", 1}}}},
+		{"page", Query{Page: -1}},
+		{"hits per page", Query{HitsPerPage: MAX_HITS_PER_PAGE + 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := client.Search(context.Background(), test.query); err == nil {
+				t.Errorf("Expected query to be rejected")
+			}
+		})
+	}
+
+	if _, err := NewClient(0); err != InvalidTimeOutErr {
+		t.Errorf("Expected InvalidTimeOutErr but got %v", err)
+	}
+}
+
+func TestHitType(t *testing.T) {
+	tests := []struct {
+		tags     []string
+		expected string
+	}{
+		{[]string{"story", "author_pg", "story_1"}, "story"},
+		{[]string{"author_pg", "comment", "story_1"}, "comment"},
+		{[]string{"poll"}, "poll"},
+		{[]string{"author_pg"}, ""},
+	}
+	for _, test := range tests {
+		if actual := (Hit{Tags: test.tags}).Type(); actual != test.expected {
+			t.Errorf("Type of %v. Expected %q Actual %q", test.tags, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package algolia
+
+import "fmt"
+
+var (
+	InvalidTimeOutErr = fmt.Errorf("Timeout must be more than 0 seconds")
+	InvalidPageErr    = fmt.Errorf("Page must not be negative and hits per page must be between 0 and 1000")
+)
+
+type InvalidFilterErr struct {
+	filter NumericFilter
+}
+
+type StatusCodeErr struct {
+	url        string
+	statusCode int
+}
+
+type InvalidHitErr struct {
+	objectID string
+}
+
+func (e *InvalidFilterErr) Error() string {
+	return fmt.Sprintf("Numeric filter %s is invalid. Fields are points, created_at_i and num_comments, operators are <, <=, =, >= and >", e.filter.String())
+}
+
+func (e *StatusCodeErr) Error() string {
+	return fmt.Sprintf("Request to %s failed with status code %d", e.url, e.statusCode)
+}
+
+func (e *InvalidHitErr) Error() string {
+	return fmt.Sprintf("Hit has an invalid object id %q", e.objectID)
+}
@@ -0,0 +1,109 @@
+package algolia
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+// A page of search results
+type Response struct {
+	Hits        []Hit  `json:"hits"`
+	NbHits      int    `json:"nbHits"`
+	Page        int    `json:"page"`
+	NbPages     int    `json:"nbPages"`
+	HitsPerPage int    `json:"hitsPerPage"`
+	Query       string `json:"query"`
+}
+
+// A story, comment or other item matching a search.
+// Fields that do not apply to the item's type are empty
+type Hit struct {
+	ObjectID    string   `json:"objectID"`
+	Tags        []string `json:"_tags"`
+	Title       string   `json:"title"`
+	URL         string   `json:"url"`
+	Author      string   `json:"author"`
+	Points      *int     `json:"points"`
+	NumComments *int     `json:"num_comments"`
+	StoryText   string   `json:"story_text"`
+	CommentText string   `json:"comment_text"`
+	// The story a comment belongs to, and its parent story or comment
+	StoryID    *int   `json:"story_id"`
+	StoryTitle string `json:"story_title"`
+	StoryURL   string `json:"story_url"`
+	ParentID   *int   `json:"parent_id"`
+	CreatedAt  int    `json:"created_at_i"`
+}
+
+// The type tags, in the order a hit's type is chosen when it has several
+var itemTypes = []string{TAG_STORY, TAG_COMMENT, TAG_POLL, TAG_POLLOPT, TAG_JOB}
+
+// Returns the item type of the hit, such as story or comment, from its tags.
+// Returns an empty string if it has no type tag
+func (h Hit) Type() string {
+	for _, itemType := range itemTypes {
+		for _, tag := range h.Tags {
+			if tag == itemType {
+				return itemType
+			}
+		}
+	}
+	return ""
+}
+
+// Converts the hit to the item the hackernews api returns for it, so it can be converted to a Story.
+// Kids and parts are not available. Returns *InvalidHitErr if the object id is not an item id
+func (h Hit) RawItem() (*hackernews.RawItem, error) {
+	id, err := strconv.Atoi(h.ObjectID)
+	if err != nil {
+		return nil, &InvalidHitErr{h.ObjectID}
+	}
+
+	item := &hackernews.RawItem{
+		ID:          id,
+		ItemType:    h.Type(),
+		By:          h.Author,
+		Timestamp:   h.CreatedAt,
+		Title:       h.Title,
+		URL:         h.URL,
+		Text:        h.StoryText,
+		Score:       intOrZero(h.Points),
+		Descendants: intOrZero(h.NumComments),
+		Parent:      intOrZero(h.ParentID),
+	}
+	if item.ItemType == TAG_COMMENT {
+		item.Text = h.CommentText
+	}
+	return item, nil
+}
+
+func intOrZero(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+// Converts the story hits of the response with converter, ranked by their position in the results.
+// Other hits are skipped. Hits that fail conversion are left out and their errors returned joined together
+func (r *Response) Stories(converter *hackernews.ItemConverter) ([]*hackernews.Story, error) {
+	stories := []*hackernews.Story{}
+	var errs []error
+	for i, hit := range r.Hits {
+		if hit.Type() != TAG_STORY {
+			continue
+		}
+		item, err := hit.RawItem()
+		if err == nil {
+			var story *hackernews.Story
+			if story, err = converter.Convert(r.Page*r.HitsPerPage+i+1, item); err == nil {
+				stories = append(stories, story)
+				continue
+			}
+		}
+		errs = append(errs, err)
+	}
+	return stories, errors.Join(errs...)
+}
@@ -0,0 +1,117 @@
+package algolia
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Tags every hit has one of
+const (
+	TAG_STORY      = "story"
+	TAG_COMMENT    = "comment"
+	TAG_POLL       = "poll"
+	TAG_POLLOPT    = "pollopt"
+	TAG_JOB        = "job"
+	TAG_ASK_HN     = "ask_hn"
+	TAG_SHOW_HN    = "show_hn"
+	TAG_FRONT_PAGE = "front_page"
+)
+
+// Fields numeric filters can compare
+const (
+	FIELD_POINTS       = "points"
+	FIELD_CREATED_AT   = "created_at_i"
+	FIELD_NUM_COMMENTS = "num_comments"
+)
+
+// The api returns at most this many hits per page
+const MAX_HITS_PER_PAGE = 1000
+
+var numericFields = map[string]bool{FIELD_POINTS: true, FIELD_CREATED_AT: true, FIELD_NUM_COMMENTS: true}
+
+var numericOps = map[string]bool{"<": true, "<=": true, "=": true, ">=": true, ">": true}
+
+// Tag of items posted by the user
+func AuthorTag(user string) string {
+	return "author_" + user
+}
+
+// Tag of a story and every comment on it
+func StoryTag(id int) string {
+	return fmt.Sprintf("story_%d", id)
+}
+
+// Matches hits with any of the tags. Tags listed separately in a query must all match
+func AnyTag(tags ...string) string {
+	return "(" + strings.Join(tags, ",") + ")"
+}
+
+// Compares a numeric field of each hit with a value, for example points >= 100
+type NumericFilter struct {
+	Field string
+	Op    string
+	Value int64
+}
+
+func (f NumericFilter) String() string {
+	return fmt.Sprintf("%s%s%d", f.Field, f.Op, f.Value)
+}
+
+// Returns *InvalidFilterErr if the field or operator is not supported
+func (f NumericFilter) Validate() error {
+	if !numericFields[f.Field] || !numericOps[f.Op] {
+		return &InvalidFilterErr{f}
+	}
+	return nil
+}
+
+// A search of the api. The zero value matches every item, newest or most relevant first
+type Query struct {
+	// Words to search for. Empty matches everything
+	Text string
+	// Hits must have every tag. Use AnyTag to match one of several
+	Tags    []string
+	Filters []NumericFilter
+	// Zero based page of results
+	Page int
+	// Zero uses the api's default of 20
+	HitsPerPage int
+}
+
+// Returns error if a filter or the page is invalid
+func (q Query) Validate() error {
+	if q.Page < 0 || q.HitsPerPage < 0 || q.HitsPerPage > MAX_HITS_PER_PAGE {
+		return InvalidPageErr
+	}
+	for _, filter := range q.Filters {
+		if err := filter.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the query string of the search
+func (q Query) values() url.Values {
+	values := url.Values{}
+	values.Set("query", q.Text)
+	if len(q.Tags) > 0 {
+		values.Set("tags", strings.Join(q.Tags, ","))
+	}
+	if len(q.Filters) > 0 {
+		filters := make([]string, len(q.Filters))
+		for i, filter := range q.Filters {
+			filters[i] = filter.String()
+		}
+		values.Set("numericFilters", strings.Join(filters, ","))
+	}
+	if q.Page > 0 {
+		values.Set("page", strconv.Itoa(q.Page))
+	}
+	if q.HitsPerPage > 0 {
+		values.Set("hitsPerPage", strconv.Itoa(q.HitsPerPage))
+	}
+	return values
+}
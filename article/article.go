@@ -0,0 +1,281 @@
+package article
+
+import (
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// The readable text and metadata of a linked page
+type Article struct {
+	URL         string     `json:"url"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Image       string     `json:"image,omitempty"`
+	Published   *time.Time `json:"published,omitempty"`
+	// Paragraphs of the main text, separated by blank lines
+	Text string `json:"text"`
+}
+
+// Paragraphs shorter than this are not counted when looking for the main text, as they are usually links or captions
+const MIN_PARAGRAPH_LENGTH = 25
+
+// Elements that never hold the main text
+var skippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true,
+	atom.Form: true, atom.Button: true, atom.Svg: true, atom.Iframe: true,
+}
+
+// Elements that start a new paragraph
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Li: true, atom.Blockquote: true, atom.Pre: true, atom.Br: true, atom.Tr: true,
+	atom.Dd: true, atom.Dt: true, atom.Figcaption: true, atom.Table: true, atom.Ul: true, atom.Ol: true,
+}
+
+// Layouts tried when parsing published dates
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// Extracts the article from the html page read from r.
+// pageURL is used to resolve a relative image url.
+// Returns NoContentErr if the page has no readable text
+func Extract(r io.Reader, pageURL string) (*Article, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	article := &Article{URL: pageURL}
+	meta := readMeta(doc)
+
+	article.Title = first(meta["og:title"], meta["twitter:title"], meta["title"], textOf(findFirst(doc, atom.H1)))
+	article.Description = first(meta["og:description"], meta["description"], meta["twitter:description"])
+	article.Image = resolve(pageURL, first(meta["og:image"], meta["twitter:image"]))
+	article.Published = parseDate(first(meta["article:published_time"], meta["datepublished"],
+		meta["date"], meta["pubdate"], meta["dc.date"], meta["time"]))
+
+	article.Text = readableText(mainContent(doc))
+	if article.Text == "" {
+		return nil, NoContentErr
+	}
+	return article, nil
+}
+
+// Returns the page's metadata keyed by lowercase meta property, name or itemprop.
+// Also includes the text of the title element as title and the first time element's datetime as time.
+// The first value of each key wins
+func readMeta(doc *html.Node) map[string]string {
+	meta := make(map[string]string)
+	set := func(key, value string) {
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if _, ok := meta[key]; !ok && key != "" && value != "" {
+			meta[key] = value
+		}
+	}
+
+	walk(doc, func(n *html.Node) bool {
+		switch n.DataAtom {
+		case atom.Meta:
+			content := attr(n, "content")
+			set(attr(n, "property"), content)
+			set(attr(n, "name"), content)
+			set(attr(n, "itemprop"), content)
+		case atom.Title:
+			set("title", textOf(n))
+		case atom.Time:
+			set("time", attr(n, "datetime"))
+		case atom.Svg:
+			return false // svg has its own title elements
+		}
+		return true
+	})
+	return meta
+}
+
+// Returns the element most likely to hold the main text.
+// Prefers article, then main, then the element whose paragraphs hold the most text
+func mainContent(doc *html.Node) *html.Node {
+	if n := findFirst(doc, atom.Article); n != nil {
+		return n
+	}
+	if n := findFirst(doc, atom.Main); n != nil {
+		return n
+	}
+
+	// each paragraph scores its parent and, by half, its grandparent
+	scores := make(map[*html.Node]int)
+	walk(doc, func(n *html.Node) bool {
+		if skippedElements[n.DataAtom] {
+			return false
+		}
+		if n.DataAtom != atom.P || n.Parent == nil {
+			return true
+		}
+		length := len(collapse(textOf(n)))
+		if length < MIN_PARAGRAPH_LENGTH {
+			return false
+		}
+		scores[n.Parent] += length
+		if n.Parent.Parent != nil {
+			scores[n.Parent.Parent] += length / 2
+		}
+		return false
+	})
+
+	var best *html.Node
+	for n, score := range scores {
+		if best == nil || score > scores[best] {
+			best = n
+		}
+	}
+	if best != nil {
+		return best
+	}
+	if body := findFirst(doc, atom.Body); body != nil {
+		return body
+	}
+	return doc
+}
+
+// Returns the text of root as paragraphs separated by blank lines, leaving out navigation, scripts and the like
+func readableText(root *html.Node) string {
+	var paragraphs []string
+	var current strings.Builder
+	flush := func() {
+		if text := collapse(current.String()); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+		current.Reset()
+	}
+
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			current.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			if skippedElements[n.DataAtom] {
+				return
+			}
+		}
+		block := blockElements[n.DataAtom]
+		if block {
+			flush()
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+		if block {
+			flush()
+		}
+	}
+	visit(root)
+	flush()
+
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// Calls visit on n and its descendants in document order.
+// The children of a node are skipped if visit returns false
+func walk(n *html.Node, visit func(*html.Node) bool) {
+	if n.Type == html.ElementNode && !visit(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, visit)
+	}
+}
+
+// Returns the first element of type a, or nil
+func findFirst(doc *html.Node, a atom.Atom) *html.Node {
+	var found *html.Node
+	walk(doc, func(n *html.Node) bool {
+		if found == nil && n.DataAtom == a {
+			found = n
+		}
+		return found == nil
+	})
+	return found
+}
+
+// Returns all the text inside n
+func textOf(n *html.Node) string {
+	if n == nil {
+		return ""
+	}
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var text strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		text.WriteString(textOf(c))
+	}
+	return collapse(text.String())
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// Replaces runs of whitespace with a single space
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Returns the first value that is not empty
+func first(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// Resolves ref against base. Returns ref unchanged if either cannot be parsed
+func resolve(base, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return b.ResolveReference(r).String()
+}
+
+// Parses value using dateLayouts. Returns nil if it is not a date
+func parseDate(value string) *time.Time {
+	for _, layout := range dateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			parsed = parsed.UTC()
+			return &parsed
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package article
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Load test data
+func helperLoadBytes(t *testing.T, name string) []byte {
+	bytes, err := ioutil.ReadFile(filepath.Join("./testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes
+}
+
+func TestExtract(t *testing.T) {
+	log.Println("Testing article extraction")
+
+	published := time.Date(2019, 7, 1, 8, 30, 0, 0, time.UTC)
+	june := time.Date(2019, 6, 30, 0, 0, 0, 0, time.UTC)
+
+	extractTests := []struct {
+		fixture  string
+		expected Article
+	}{
+		{"blog.html", Article{
+			URL:         "https://blog.example.com/posts/plain-html",
+			Title:       "Why I still write plain HTML",
+			Description: "A short defence of hand written pages.",
+			Image:       "https://blog.example.com/images/cover.png",
+			Published:   &published,
+			Text: "Why I still write plain HTML\n\n" +
+				"Every few years a new framework promises to make building websites easier. Most of them do, for a while.\n\n" +
+				"Plain HTML has outlived all of them. A page I wrote in 2004 still renders & reads fine today.\n\n" +
+				"No build step\n\nNo dependencies",
+		}},
+		{"divs.html", Article{
+			URL:         "https://example.com/releases/2.0",
+			Title:       "Release notes for version 2.0",
+			Description: "What changed in version 2.0.",
+			Image:       "https://cdn.example.com/release.png",
+			Published:   &june,
+			Text: "Version 2.0 rewrites the storage engine so writes no longer block reads.\n\n" +
+				"Upgrading is automatic. Existing databases are migrated the first time they are opened.\n\n" +
+				"Thanks to everyone who tested the release candidates.",
+		}},
+	}
+
+	for _, test := range extractTests {
+		test := test
+		t.Run(test.fixture, func(t *testing.T) {
+			t.Parallel()
+			article, err := Extract(bytes.NewReader(helperLoadBytes(t, test.fixture)), test.expected.URL)
+			if err != nil {
+				t.Fatalf("Failed to extract %s. Reason : %s", test.fixture, err.Error())
+			}
+			if article.Title != test.expected.Title {
+				t.Errorf("Title incorrect. \n\t Expected %q Actual %q", test.expected.Title, article.Title)
+			}
+			if article.Description != test.expected.Description {
+				t.Errorf("Description incorrect. \n\t Expected %q Actual %q", test.expected.Description, article.Description)
+			}
+			if article.Image != test.expected.Image {
+				t.Errorf("Image incorrect. \n\t Expected %q Actual %q", test.expected.Image, article.Image)
+			}
+			if article.Published == nil || !article.Published.Equal(*test.expected.Published) {
+				t.Errorf("Published incorrect. \n\t Expected %v Actual %v", test.expected.Published, article.Published)
+			}
+			if article.Text != test.expected.Text {
+				t.Errorf("Text incorrect. \n\t Expected %q \n\t Actual %q", test.expected.Text, article.Text)
+			}
+		})
+	}
+}
+
+func TestExtractNoContent(t *testing.T) {
+	if _, err := Extract(bytes.NewReader(helperLoadBytes(t, "empty.html")), "https://example.com/"); err != NoContentErr {
+		t.Errorf("Expected NoContentErr but got %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+package article
+
+import "fmt"
+
+var (
+	InvalidTimeOutErr = fmt.Errorf("Timeout must be more than 0 seconds")
+	NoContentErr      = fmt.Errorf("Page has no readable text")
+)
+
+type StatusCodeErr struct {
+	url        string
+	statusCode int
+}
+
+func (e *StatusCodeErr) Error() string {
+	return fmt.Sprintf("Request to %s failed with status code %d", e.url, e.statusCode)
+}
+
+type NotHTMLErr struct {
+	url         string
+	contentType string
+}
+
+func (e *NotHTMLErr) Error() string {
+	return fmt.Sprintf("%s is not an html page. Content type is %q", e.url, e.contentType)
+}
@@ -0,0 +1,123 @@
+package article
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// Pages larger than this are cut off before extraction
+	MAX_PAGE_BYTES = 5 << 20
+	USER_AGENT     = "hn-scraper (+https://github.com/alis93/hn-scraper)"
+)
+
+// Fetches linked articles and extracts their readable text.
+// Uses the same retry policy and timeouts as the hackernews client.
+type Fetcher struct {
+	http      *http.Client
+	retry     hackernews.RetryPolicy
+	userAgent string
+	maxBytes  int64
+	logger    *slog.Logger
+}
+
+// Optional settings applied when creating a fetcher
+type FetcherOption func(*Fetcher)
+
+// Sets how failed requests are retried. By default requests are not retried.
+func WithRetries(policy hackernews.RetryPolicy) FetcherOption {
+	return func(f *Fetcher) {
+		f.retry = policy
+	}
+}
+
+// Sets the User-Agent header sent with each request.
+func WithUserAgent(userAgent string) FetcherOption {
+	return func(f *Fetcher) {
+		f.userAgent = userAgent
+	}
+}
+
+// Sets how many bytes of each page are read. Defaults to MAX_PAGE_BYTES.
+func WithMaxBytes(maxBytes int64) FetcherOption {
+	return func(f *Fetcher) {
+		f.maxBytes = maxBytes
+	}
+}
+
+// Logs requests at debug level and retries at warn level to logger.
+// By default nothing is logged.
+func WithLogger(logger *slog.Logger) FetcherOption {
+	return func(f *Fetcher) {
+		f.logger = logger
+	}
+}
+
+// Creates a fetcher with given timeout in seconds
+func NewFetcher(timeout int, opts ...FetcherOption) (*Fetcher, error) {
+	if timeout <= 0 {
+		return nil, InvalidTimeOutErr
+	}
+
+	fetcher := &Fetcher{
+		http:      &http.Client{Timeout: time.Duration(timeout) * time.Second},
+		userAgent: USER_AGENT,
+		maxBytes:  MAX_PAGE_BYTES,
+		logger:    slog.New(slog.DiscardHandler),
+	}
+	for _, opt := range opts {
+		opt(fetcher)
+	}
+	return fetcher, nil
+}
+
+// Fetches the page at pageURL and extracts its article.
+// Returns error if the request fails, the page is not html or has no readable text
+func (f *Fetcher) Fetch(ctx context.Context, pageURL string) (*Article, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	start := time.Now()
+	res, err := f.retry.Do(f.http, req, func(res *http.Response, err error) {
+		if err == nil {
+			err = &StatusCodeErr{pageURL, res.StatusCode}
+		}
+		f.logger.Warn("retrying article request", "url", pageURL, "error", err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	f.logger.Debug("article request", "url", pageURL, "status", res.StatusCode, "duration", time.Since(start))
+
+	if res.StatusCode != http.StatusOK {
+		return nil, &StatusCodeErr{pageURL, res.StatusCode}
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	if mediaType, _, _ := mime.ParseMediaType(contentType); contentType != "" &&
+		mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, &NotHTMLErr{pageURL, contentType}
+	}
+
+	// decode to UTF-8 using the header, a BOM or the page's meta charset
+	body, err := charset.NewReader(io.LimitReader(res.Body, f.maxBytes), contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	// redirects change the url relative links are resolved against
+	return Extract(body, res.Request.URL.String())
+}
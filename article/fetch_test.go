@@ -0,0 +1,79 @@
+package article
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+func TestFetch(t *testing.T) {
+	log.Println("Testing fetching articles")
+
+	// fail the first request to the blog to test retries
+	var blogCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/blog":
+			if atomic.AddInt32(&blogCalls, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(helperLoadBytes(t, "blog.html"))
+		case "/latin1":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write(helperLoadBytes(t, "latin1.html"))
+		case "/paper.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("%PDF-1.4"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fetcher, err := NewFetcher(5, WithRetries(hackernews.RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	article, err := fetcher.Fetch(context.Background(), server.URL+"/blog")
+	if err != nil {
+		t.Fatalf("Expected fetch to succeed after a retry. Reason : %s", err.Error())
+	}
+	if article.Image != server.URL+"/images/cover.png" {
+		t.Errorf("Image incorrect. \n\t Expected %s Actual %s", server.URL+"/images/cover.png", article.Image)
+	}
+
+	article, err = fetcher.Fetch(context.Background(), server.URL+"/latin1")
+	if err != nil {
+		t.Fatalf("Failed to fetch latin1 page. Reason : %s", err.Error())
+	}
+	if article.Title != "Café" || article.Text != "Un café crème, s'il vous plaît." {
+		t.Errorf("Latin1 page not decoded. Title %q Text %q", article.Title, article.Text)
+	}
+
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/paper.pdf"); err == nil {
+		t.Errorf("Expected pdf to be rejected")
+	} else if _, ok := err.(*NotHTMLErr); !ok {
+		t.Errorf("Expected *NotHTMLErr but got %v", err)
+	}
+
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/missing"); err == nil {
+		t.Errorf("Expected missing page to fail")
+	} else if _, ok := err.(*StatusCodeErr); !ok {
+		t.Errorf("Expected *StatusCodeErr but got %v", err)
+	}
+}
+
+func TestNewFetcherInvalidTimeout(t *testing.T) {
+	if _, err := NewFetcher(0); err != InvalidTimeOutErr {
+		t.Errorf("Expected InvalidTimeOutErr but got %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+// A comment and its replies, in the order hackernews ranks them
+type comment struct {
+	ID      int        `json:"id"`
+	Author  string     `json:"author"`
+	Time    int        `json:"time"`
+	Text    string     `json:"text"`
+	Deleted bool       `json:"deleted,omitempty"`
+	Dead    bool       `json:"dead,omitempty"`
+	Replies []*comment `json:"replies,omitempty"`
+}
+
+// A story and its comments
+type thread struct {
+	ID       int        `json:"id"`
+	Title    string     `json:"title"`
+	Author   string     `json:"author"`
+	Comments []*comment `json:"comments"`
+}
+
+// Prints the comment tree of a story, or of a comment's replies.
+// Items are read from the source, so the html source, which has no comments, prints none.
+func defineComments(flags *flag.FlagSet) func(args []string) {
+	depth := flags.Int("depth", 0, "How many levels of replies to print. 0 prints every level")
+	workers := flags.Int("workers", 16, "How many comments to fetch at once")
+	format := flags.String("format", "text", "Output format. Either text or json")
+	logs := addLogFlags(flags)
+	settings := addConfigFlags(flags)
+	sources := addSourceFlags(flags)
+	return func(args []string) {
+		logs.setup()
+		cfg := settings.load()
+
+		if *format != "text" && *format != "json" {
+			fatal("invalid --format, must be text or json", "value", *format)
+		}
+		if *depth < 0 || *workers <= 0 {
+			fatal("--depth must be at least 0 and --workers more than 0", "depth", *depth, "workers", *workers)
+		}
+		if len(args) != 1 {
+			fatal("a story id is required, for example hn-scraper comments 20324021")
+		}
+		id, err := strconv.Atoi(args[0])
+		if err != nil || id <= 0 {
+			fatal("invalid story id", "value", args[0])
+		}
+
+		source := sources.open(cfg)
+		ctx := context.Background()
+		item, err := source.Item(ctx, id)
+		if err != nil {
+			fatalErr(err)
+		}
+		tree := &thread{ID: item.ID, Title: item.Title, Author: item.By}
+		tree.Comments = fetchReplies(ctx, source, item.Kids, *depth, 1, make(chan struct{}, *workers))
+		sources.close()
+
+		if *format == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "    ")
+			encoder.Encode(tree)
+			return
+		}
+		printThread(os.Stdout, tree)
+	}
+}
+
+// Retrieves the comments with the ids in kids and their replies, down to depth levels below the story.
+// level is the level of kids, and slots limits how many comments are fetched at once.
+// Comments that cannot be retrieved are logged and left out
+func fetchReplies(ctx context.Context, source hackernews.Source, kids []int, depth, level int, slots chan struct{}) []*comment {
+	replies := make([]*comment, len(kids))
+	var wg sync.WaitGroup
+	for i, id := range kids {
+		wg.Add(1)
+		go func(i, id int) {
+			defer wg.Done()
+			// the slot is released before fetching replies, so deep threads never wait on themselves
+			slots <- struct{}{}
+			item, err := source.Item(ctx, id)
+			<-slots
+			if err != nil {
+				Logger.Warn("unable to get comment", "comment_id", id, "error", err, "error_kind", hackernews.ErrorKind(err))
+				return
+			}
+
+			reply := &comment{ID: item.ID, Author: item.By, Time: item.Timestamp, Text: hackernews.PlainText(item.Text), Deleted: item.Deleted, Dead: item.Dead}
+			if depth == 0 || level < depth {
+				reply.Replies = fetchReplies(ctx, source, item.Kids, depth, level+1, slots)
+			}
+			replies[i] = reply
+		}(i, id)
+	}
+	wg.Wait()
+
+	fetched := make([]*comment, 0, len(replies))
+	for _, reply := range replies {
+		if reply != nil {
+			fetched = append(fetched, reply)
+		}
+	}
+	return fetched
+}
+
+// Writes the thread with each reply indented under its parent
+func printThread(w io.Writer, tree *thread) {
+	fmt.Fprintf(w, "%s by %s https://news.ycombinator.com/item?id=%d\n", tree.Title, tree.Author, tree.ID)
+	for _, c := range tree.Comments {
+		printComment(w, c, 1)
+	}
+}
+
+func printComment(w io.Writer, c *comment, level int) {
+	indent := strings.Repeat("    ", level)
+	switch {
+	case c.Deleted:
+		fmt.Fprintf(w, "\n%s[deleted]\n", indent)
+	case c.Dead:
+		fmt.Fprintf(w, "\n%s[dead]\n", indent)
+	default:
+		posted := time.Unix(int64(c.Time), 0).UTC().Format("2006-01-02 15:04")
+		fmt.Fprintf(w, "\n%s%s at %s\n", indent, c.Author, posted)
+		fmt.Fprintf(w, "%s%s\n", indent, strings.ReplaceAll(c.Text, "\n", "\n"+indent))
+	}
+	for _, reply := range c.Replies {
+		printComment(w, reply, level+1)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+func TestFetchReplies(t *testing.T) {
+	log.Println("Testing fetching comment trees")
+
+	client, _, api := helperFakeAPI(t)
+	api.SetItem(1, hackernews.RawItem{ID: 1, ItemType: "story", By: "moks", Title: "Event sourcing", Kids: []int{2, 3, 5, 6}})
+	api.SetItem(2, hackernews.RawItem{ID: 2, ItemType: "comment", By: "pg", Text: "First<p>Second &amp; last", Kids: []int{4}})
+	api.SetItem(4, hackernews.RawItem{ID: 4, ItemType: "comment", By: "dang", Text: "A reply"})
+	api.SetItem(5, hackernews.RawItem{ID: 5, ItemType: "comment", Deleted: true})
+	api.SetItem(6, hackernews.RawItem{ID: 6, ItemType: "comment", By: "moks", Text: "Last"})
+	// 3 has no fixture, so the fake api answers null
+
+	tests := []struct {
+		name     string
+		depth    int
+		expected []string
+		absent   []string
+	}{
+		{"every level", 0, []string{"    pg at", "    First\n    Second & last", "        dang at", "        A reply", "    [deleted]", "    moks at"}, []string{"<p>"}},
+		{"top level only", 1, []string{"    pg at", "    [deleted]", "    Last"}, []string{"dang", "A reply"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tree := &thread{ID: 1, Title: "Event sourcing", Author: "moks"}
+			tree.Comments = fetchReplies(context.Background(), client, []int{2, 3, 5, 6}, test.depth, 1, make(chan struct{}, 2))
+
+			ids := []int{}
+			for _, c := range tree.Comments {
+				ids = append(ids, c.ID)
+			}
+			// the missing comment is left out and the rest keep their order
+			if len(ids) != 3 || ids[0] != 2 || ids[1] != 5 || ids[2] != 6 {
+				t.Errorf("Expected comments [2 5 6] but got %v", ids)
+			}
+
+			var output bytes.Buffer
+			printThread(&output, tree)
+			for _, want := range test.expected {
+				if !strings.Contains(output.String(), want) {
+					t.Errorf("Expected output to contain %q. \n%s", want, output.String())
+				}
+			}
+			for _, unwanted := range test.absent {
+				if strings.Contains(output.String(), unwanted) {
+					t.Errorf("Expected output not to contain %q. \n%s", unwanted, output.String())
+				}
+			}
+		})
+	}
+}
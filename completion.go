@@ -0,0 +1,168 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Shells with completion scripts
+var shells = []string{"bash", "fish", "zsh"}
+
+// A flag of a command, as offered by the completion scripts
+type completionFlag struct {
+	name  string
+	usage string
+	// false for bool flags, which are given without a value
+	takesValue bool
+}
+
+// Prints the completion script of the shell named in args
+func runCompletion(args []string) {
+	if len(args) != 1 {
+		fatal("a shell is required, one of bash, zsh or fish. For example hn-scraper completion bash")
+	}
+	if !writeCompletion(os.Stdout, args[0]) {
+		fatal("unknown shell, must be bash, zsh or fish", "shell", args[0])
+	}
+}
+
+// Writes the completion script of shell. Returns false if there is no script for it
+func writeCompletion(w io.Writer, shell string) bool {
+	switch shell {
+	case "bash":
+		writeBashCompletion(w)
+	case "fish":
+		writeFishCompletion(w)
+	case "zsh":
+		writeZshCompletion(w)
+	default:
+		return false
+	}
+	return true
+}
+
+// Returns the flags of a command, sorted by name
+func commandFlags(name string) []completionFlag {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	commands[name].define(flags)
+
+	found := []completionFlag{}
+	flags.VisitAll(func(f *flag.Flag) {
+		boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+		found = append(found, completionFlag{f.Name, f.Usage, !ok || !boolFlag.IsBoolFlag()})
+	})
+	return found
+}
+
+// Returns the names of the commands with flags, sorted
+func flagCommandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func writeBashCompletion(w io.Writer) {
+	fmt.Fprintf(w, "# bash completion for %s\n", PROGRAM)
+	fmt.Fprintf(w, "# Load it with: source <(%s completion bash)\n", PROGRAM)
+	fmt.Fprintf(w, "_hn_scraper() {\n")
+	fmt.Fprintf(w, "    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	fmt.Fprintf(w, "    if [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(commandNames(), " "))
+	fmt.Fprintf(w, "        return\n")
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "    local flags=\"\"\n")
+	fmt.Fprintf(w, "    case \"${COMP_WORDS[1]}\" in\n")
+	fmt.Fprintf(w, "        completion) COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")); return ;;\n", strings.Join(shells, " "))
+	fmt.Fprintf(w, "        help) COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")); return ;;\n", strings.Join(flagCommandNames(), " "))
+	for _, name := range flagCommandNames() {
+		names := []string{}
+		for _, f := range commandFlags(name) {
+			names = append(names, "--"+f.name)
+		}
+		fmt.Fprintf(w, "        %s) flags=\"%s\" ;;\n", name, strings.Join(names, " "))
+	}
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "    if [[ \"$cur\" == -* ]]; then\n")
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W \"$flags\" -- \"$cur\"))\n")
+	fmt.Fprintf(w, "    else\n")
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "complete -o filenames -F _hn_scraper %s\n", PROGRAM)
+}
+
+func writeZshCompletion(w io.Writer) {
+	// single quotes cannot be escaped inside single quotes, so each one closes and reopens the string
+	quote := func(s string) string { return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'" }
+	// brackets end the description of an _arguments spec
+	describe := strings.NewReplacer("[", `\[`, "]", `\]`)
+
+	fmt.Fprintf(w, "#compdef %s\n", PROGRAM)
+	fmt.Fprintf(w, "# zsh completion for %s\n", PROGRAM)
+	fmt.Fprintf(w, "# Save it as _%s in a directory of $fpath, or load it with: source <(%s completion zsh)\n", PROGRAM, PROGRAM)
+	fmt.Fprintf(w, "_hn_scraper() {\n")
+	fmt.Fprintf(w, "    local -a commands\n")
+	fmt.Fprintf(w, "    commands=(\n")
+	for _, name := range commandNames() {
+		fmt.Fprintf(w, "        %s\n", quote(name+":"+commandSummary(name)))
+	}
+	fmt.Fprintf(w, "    )\n")
+	fmt.Fprintf(w, "    if (( CURRENT == 2 )); then\n")
+	fmt.Fprintf(w, "        _describe 'command' commands\n")
+	fmt.Fprintf(w, "        return\n")
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "    # complete the flags of the command as if it were run on its own\n")
+	fmt.Fprintf(w, "    shift words\n")
+	fmt.Fprintf(w, "    (( CURRENT-- ))\n")
+	fmt.Fprintf(w, "    case \"$words[1]\" in\n")
+	fmt.Fprintf(w, "        completion) _values 'shell' %s ;;\n", strings.Join(shells, " "))
+	fmt.Fprintf(w, "        help) _values 'command' %s ;;\n", strings.Join(flagCommandNames(), " "))
+	for _, name := range flagCommandNames() {
+		fmt.Fprintf(w, "        %s)\n", name)
+		fmt.Fprintf(w, "            _arguments \\\n")
+		for _, f := range commandFlags(name) {
+			spec := fmt.Sprintf("--%s[%s]", f.name, describe.Replace(f.usage))
+			if f.takesValue {
+				spec += ":value:_files"
+			}
+			fmt.Fprintf(w, "                %s \\\n", quote(spec))
+		}
+		fmt.Fprintf(w, "                '*:argument:_files'\n")
+		fmt.Fprintf(w, "            ;;\n")
+	}
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "compdef _hn_scraper %s\n", PROGRAM)
+}
+
+func writeFishCompletion(w io.Writer) {
+	quote := func(s string) string {
+		return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+	}
+
+	fmt.Fprintf(w, "# fish completion for %s\n", PROGRAM)
+	fmt.Fprintf(w, "# Save it as ~/.config/fish/completions/%s.fish, or load it with: %s completion fish | source\n", PROGRAM, PROGRAM)
+	fmt.Fprintf(w, "complete -c %s -f\n", PROGRAM)
+	for _, name := range commandNames() {
+		fmt.Fprintf(w, "complete -c %s -n __fish_use_subcommand -a %s -d %s\n", PROGRAM, name, quote(commandSummary(name)))
+	}
+	fmt.Fprintf(w, "complete -c %s -n '__fish_seen_subcommand_from completion' -a %s\n", PROGRAM, quote(strings.Join(shells, " ")))
+	fmt.Fprintf(w, "complete -c %s -n '__fish_seen_subcommand_from help' -a %s\n", PROGRAM, quote(strings.Join(flagCommandNames(), " ")))
+	for _, name := range flagCommandNames() {
+		for _, f := range commandFlags(name) {
+			value := ""
+			if f.takesValue {
+				// values are often paths, so offer files
+				value = " -r -F"
+			}
+			fmt.Fprintf(w, "complete -c %s -n '__fish_seen_subcommand_from %s' -l %s%s -d %s\n", PROGRAM, name, f.name, value, quote(f.usage))
+		}
+	}
+}
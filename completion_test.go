@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCompletion(t *testing.T) {
+	log.Println("Testing shell completion scripts")
+
+	for _, shell := range shells {
+		t.Run(shell, func(t *testing.T) {
+			var script bytes.Buffer
+			if !writeCompletion(&script, shell) {
+				t.Fatalf("Expected a script for %s", shell)
+			}
+			for _, want := range append(commandNames(), "--posts", "--depth", "--fail-on", strings.Join(shells, " ")) {
+				if shell == "fish" {
+					// fish takes flag names without dashes
+					want = strings.TrimPrefix(want, "--")
+				}
+				if !strings.Contains(script.String(), want) {
+					t.Errorf("Expected %s script to contain %q", shell, want)
+				}
+			}
+
+			// check the syntax if the shell is installed
+			path, err := exec.LookPath(shell)
+			if err != nil || shell == "fish" {
+				return
+			}
+			cmd := exec.Command(path, "-n")
+			cmd.Stdin = &script
+			if output, err := cmd.CombinedOutput(); err != nil {
+				t.Errorf("%s script is invalid. %s\n%s", shell, err, output)
+			}
+		})
+	}
+
+	if writeCompletion(&bytes.Buffer{}, "powershell") {
+		t.Errorf("Expected no script for powershell")
+	}
+}
+
+func TestCommandFlags(t *testing.T) {
+	log.Println("Testing the flags of each command")
+
+	for _, name := range flagCommandNames() {
+		flags := commandFlags(name)
+		if len(flags) == 0 {
+			t.Errorf("Expected %s to have flags", name)
+		}
+		for _, f := range flags {
+			if f.name == "dedupe" && f.takesValue {
+				t.Errorf("Expected --dedupe of %s to be a bool flag", name)
+			}
+			if f.name == "log-level" && !f.takesValue {
+				t.Errorf("Expected --log-level of %s to take a value", name)
+			}
+		}
+	}
+}
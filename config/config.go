@@ -0,0 +1,203 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/alis93/hn-scraper/alerts"
+	"github.com/alis93/hn-scraper/digest"
+	"github.com/alis93/hn-scraper/hackernews"
+	"gopkg.in/yaml.v3"
+)
+
+// Settings for the client and converter, loadable from a YAML, JSON or TOML file.
+type Config struct {
+	// Converter preset the file's converter settings are applied on top of
+	Preset    string                     `json:"preset" yaml:"preset" toml:"preset"`
+	Client    ClientConfig               `json:"client" yaml:"client" toml:"client"`
+	Converter hackernews.ConverterConfig `json:"converter" yaml:"converter" toml:"converter"`
+	// Rules and webhooks used by watch
+	Alerts alerts.Config `json:"alerts" yaml:"alerts" toml:"alerts"`
+	// Recipients and mail server used by digest
+	Digest digest.Config `json:"digest" yaml:"digest" toml:"digest"`
+}
+
+// Settings for the hackernews client
+type ClientConfig struct {
+	// Request timeout in seconds
+	Timeout int    `json:"timeout" yaml:"timeout" toml:"timeout"`
+	APIURL  string `json:"apiUrl" yaml:"apiUrl" toml:"apiUrl"`
+	Retries int    `json:"retries" yaml:"retries" toml:"retries"`
+	// Wait before the first retry, for example 500ms. Doubled after each retry
+	Backoff Duration `json:"backoff" yaml:"backoff" toml:"backoff"`
+}
+
+// A time.Duration written as a string such as 500ms or 2s in config files
+type Duration time.Duration
+
+// Returns the default config using the given converter preset.
+// An empty preset means the default preset
+func Default(preset string) (*Config, error) {
+	if preset == "" {
+		preset = "default"
+	}
+	converter, err := hackernews.ConverterPreset(preset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Config{
+		Preset: preset,
+		Client: ClientConfig{
+			Timeout: 5,
+			Retries: 2,
+			Backoff: Duration(500 * time.Millisecond),
+		},
+		Converter: converter,
+		Digest:    digest.DefaultConfig,
+	}, nil
+}
+
+// Loads the config file at path. The format is chosen by the extension: .yaml, .yml, .json or .toml.
+// Settings left out of the file keep their defaults. Unknown settings are an error so typos are caught.
+// If preset is not empty it is used instead of the preset named in the file.
+func Load(path, preset string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	decode, err := decoderFor(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// find the preset first, as the rest of the file is applied on top of it
+	if preset == "" {
+		named := &struct {
+			Preset string `json:"preset" yaml:"preset" toml:"preset"`
+		}{}
+		if err := decode(data, named, false); err != nil {
+			return nil, &ParseErr{path, err}
+		}
+		preset = named.Preset
+	}
+
+	cfg, err := Default(preset)
+	if err != nil {
+		return nil, err
+	}
+	if err := decode(data, cfg, true); err != nil {
+		return nil, &ParseErr{path, err}
+	}
+	cfg.Preset = preset
+	if cfg.Preset == "" {
+		cfg.Preset = "default"
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, &ParseErr{path, err}
+	}
+	return cfg, nil
+}
+
+// Decodes data into v, returning error on unknown fields if strict is set
+type decodeFunc func(data []byte, v interface{}, strict bool) error
+
+func decoderFor(path string) (decodeFunc, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return decodeJSON, nil
+	case ".yaml", ".yml":
+		return decodeYAML, nil
+	case ".toml":
+		return decodeTOML, nil
+	}
+	return nil, &UnsupportedFormatErr{path}
+}
+
+func decodeJSON(data []byte, v interface{}, strict bool) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	return ignoreEOF(decoder.Decode(v))
+}
+
+func decodeYAML(data []byte, v interface{}, strict bool) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(strict)
+	return ignoreEOF(decoder.Decode(v))
+}
+
+func decodeTOML(data []byte, v interface{}, strict bool) error {
+	meta, err := toml.Decode(string(data), v)
+	if err != nil {
+		return err
+	}
+	if undecoded := meta.Undecoded(); strict && len(undecoded) > 0 {
+		return fmt.Errorf("unknown setting %s", undecoded[0].String())
+	}
+	return nil
+}
+
+// An empty file is an empty config
+func ignoreEOF(err error) error {
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// Returns error if the client or converter settings are invalid
+func (cfg *Config) Validate() error {
+	if cfg.Client.Timeout <= 0 {
+		return hackernews.InvalidTimeOutErr
+	}
+	if cfg.Client.Retries < 0 {
+		return NegativeRetriesErr
+	}
+	// alerts are optional, but must be complete if any are configured
+	if len(cfg.Alerts.Rules) > 0 || len(cfg.Alerts.Webhooks) > 0 {
+		if err := cfg.Alerts.Validate(); err != nil {
+			return err
+		}
+	}
+	return cfg.Converter.Validate()
+}
+
+// Returns how failed requests are retried with these settings
+func (c ClientConfig) RetryPolicy() hackernews.RetryPolicy {
+	return hackernews.RetryPolicy{MaxRetries: c.Retries, Backoff: time.Duration(c.Backoff)}
+}
+
+// Returns the options to create a client with these settings
+func (c ClientConfig) Options() []hackernews.ClientOption {
+	opts := []hackernews.ClientOption{
+		hackernews.WithRetries(c.RetryPolicy()),
+	}
+	if c.APIURL != "" {
+		opts = append(opts, hackernews.WithAPIURL(c.APIURL))
+	}
+	return opts
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"log"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alis93/hn-scraper/alerts"
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoad(t *testing.T) {
+	log.Println("Testing loading config files in each format")
+
+	expected, err := Default("strict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected.Client.Timeout = 10
+	expected.Client.Retries = 3
+	expected.Client.Backoff = Duration(250 * time.Millisecond)
+	expected.Converter.MinPoints = 50
+	expected.Converter.Strings.Ellipsis = "..."
+	expected.Converter.URLPolicy.AllowedSchemes = []string{"https"}
+
+	for _, name := range []string{"config.yaml", "config.json", "config.toml"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			cfg, err := Load(filepath.Join("testdata", name), "")
+			if err != nil {
+				t.Fatalf("Failed to load config. Reason : %s", err.Error())
+			}
+			if !cmp.Equal(cfg, expected) {
+				t.Errorf("Loaded config incorrect. \n%s", cmp.Diff(expected, cfg))
+			}
+			// settings left out of the file come from the strict preset
+			if cfg.Converter.MinComments != hackernews.ConverterPresets["strict"].MinComments || !cfg.Converter.URLPolicy.RejectPrivate {
+				t.Errorf("Expected settings not in the file to come from the preset. Got %+v", cfg.Converter)
+			}
+		})
+	}
+}
+
+func TestLoadPresetOverride(t *testing.T) {
+	log.Println("Testing the preset argument overrides the file")
+
+	cfg, err := Load(filepath.Join("testdata", "config.yaml"), "lenient")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Preset != "lenient" || cfg.Converter.MinComments != 0 || cfg.Converter.MinPoints != 50 {
+		t.Errorf("Expected lenient preset with minPoints from the file. Got %+v", cfg.Converter)
+	}
+
+	if _, err := Load(filepath.Join("testdata", "config.yaml"), "nonsense"); err == nil {
+		t.Errorf("Expected an error for an unknown preset")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	log.Println("Testing invalid config files")
+
+	errorTests := []string{
+		"typo.yaml",
+		"invalid.toml",
+		"missing.json",
+		"config.ini",
+	}
+	for _, name := range errorTests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(filepath.Join("testdata", name), ""); err == nil {
+				t.Errorf("Expected %s to fail to load", name)
+			}
+		})
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+	cfg, _ := Default("")
+	cfg.Client.APIURL = "http://localhost:8080/v0"
+	if opts := cfg.Client.Options(); len(opts) != 2 {
+		t.Errorf("Expected retry and api url options but got %d options", len(opts))
+	}
+	if _, err := hackernews.NewClient(cfg.Client.Timeout, cfg.Client.Options()...); err != nil {
+		t.Errorf("Failed to create client from config. Reason : %s", err.Error())
+	}
+}
+
+func TestLoadAlerts(t *testing.T) {
+	cfg, err := Load(filepath.Join("testdata", "alerts.yaml"), "")
+	if err != nil {
+		t.Fatalf("Failed to load config. Reason : %s", err.Error())
+	}
+	expected := alerts.Config{
+		Rules: []alerts.Rule{
+			{Name: "product", Title: `(?i)\bacme\b`, MinPoints: 20},
+			{Name: "rival", Domain: "rival.com", Webhooks: []string{"team"}},
+		},
+		Webhooks: []alerts.Webhook{{Name: "team", URL: "https://hooks.example.com/hn", Secret: "s3cret"}},
+	}
+	if !cmp.Equal(cfg.Alerts, expected) {
+		t.Errorf("Loaded alerts incorrect. \n%s", cmp.Diff(expected, cfg.Alerts))
+	}
+
+	if _, err := Load(filepath.Join("testdata", "bad_alerts.yaml"), ""); err == nil {
+		t.Errorf("Expected a rule without conditions to be an error")
+	}
+}
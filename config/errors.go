@@ -0,0 +1,26 @@
+package config
+
+import "fmt"
+
+var NegativeRetriesErr = fmt.Errorf("Retries must not be negative")
+
+type UnsupportedFormatErr struct {
+	path string
+}
+
+type ParseErr struct {
+	path string
+	err  error
+}
+
+func (e *UnsupportedFormatErr) Error() string {
+	return fmt.Sprintf("Config file %s must end in .yaml, .yml, .json or .toml", e.path)
+}
+
+func (e *ParseErr) Error() string {
+	return fmt.Sprintf("Invalid config file %s. \t %s", e.path, e.err.Error())
+}
+
+func (e *ParseErr) Unwrap() error {
+	return e.err
+}
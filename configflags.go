@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/alis93/hn-scraper/config"
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+// The config flags of a command
+type configFlags struct {
+	path   *string
+	preset *string
+}
+
+// Adds --config and --preset to flags
+func addConfigFlags(flags *flag.FlagSet) *configFlags {
+	return &configFlags{
+		path:   flags.String("config", "", "YAML, JSON or TOML file with client and converter settings"),
+		preset: flags.String("preset", "", "Converter preset. One of "+strings.Join(hackernews.PresetNames(), ", ")),
+	}
+}
+
+// Loads the config file, or the defaults if no file was given.
+// Exits if the config is invalid
+func (c *configFlags) load() *config.Config {
+	var cfg *config.Config
+	var err error
+	if *c.path == "" {
+		cfg, err = config.Default(*c.preset)
+	} else {
+		cfg, err = config.Load(*c.path, *c.preset)
+	}
+	if err != nil {
+		fatalErr(err)
+	}
+	return cfg
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/alis93/hn-scraper/article"
+	"github.com/alis93/hn-scraper/config"
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+// A story printed with the article it links to
+type storyWithContent struct {
+	*hackernews.Story
+	Content *article.Article `json:"content"`
+}
+
+func (s storyWithContent) String() string {
+	prettyJson, _ := json.MarshalIndent(s, "", "    ")
+	return string(prettyJson)
+}
+
+// Creates an article fetcher with the same timeout and retries as the hackernews client
+func newFetcher(cfg config.ClientConfig) (*article.Fetcher, error) {
+	return article.NewFetcher(cfg.Timeout, article.WithRetries(cfg.RetryPolicy()), article.WithLogger(Logger))
+}
+
+// Collects the articles of stories fetched by concurrent goroutines
+type contents struct {
+	fetcher *article.Fetcher
+	mu      sync.Mutex
+	byStory map[int]*article.Article
+}
+
+func newContents(fetcher *article.Fetcher) *contents {
+	return &contents{fetcher: fetcher, byStory: make(map[int]*article.Article)}
+}
+
+// Fetches the article story links to, stopping if ctx is cancelled.
+// Failures are logged and the story is printed without content
+func (c *contents) fetch(ctx context.Context, story *hackernews.Story) {
+	if story == nil {
+		return
+	}
+	content, err := c.fetcher.Fetch(ctx, story.URL)
+	if err != nil {
+		Logger.Warn("unable to fetch article", "story_id", story.ID, "url", story.URL, "error", err)
+		return
+	}
+	c.mu.Lock()
+	c.byStory[story.ID] = content
+	c.mu.Unlock()
+}
+
+// Returns the story with its article, or just the story if articles are not being fetched
+func (c *contents) attach(story *hackernews.Story) interface{} {
+	if c == nil || story == nil {
+		return story
+	}
+	c.mu.Lock()
+	content := c.byStory[story.ID]
+	c.mu.Unlock()
+	return storyWithContent{story, content}
+}
+
+// Prints the story, with its article if there is one.
+// If ndjson is set the story is printed on a single line
+func (c *contents) print(story *hackernews.Story, ndjson bool) {
+	if !ndjson {
+		fmt.Println(c.attach(story))
+		return
+	}
+	if story != nil {
+		line, _ := json.Marshal(c.attach(story))
+		fmt.Println(string(line))
+	}
+}
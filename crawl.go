@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/alis93/hn-scraper/crawler"
+)
+
+// Crawls every item in a range of ids into sharded NDJSON files.
+// Re-running with the same arguments resumes an interrupted crawl.
+func defineCrawl(flags *flag.FlagSet) func(args []string) {
+	from := flags.Int("from", 1, "First item id to fetch")
+	to := flags.Int("to", 0, "Last item id to fetch. Defaults to the newest item")
+	outDir := flags.String("out", "", "Directory to write shards and checkpoint into")
+	workers := flags.Int("workers", 16, "How many items to fetch concurrently")
+	shardSize := flags.Int("shard-size", 100000, "How many item ids each shard file covers")
+	report := flags.Duration("report", 10*time.Second, "How often to report throughput")
+	logs := addLogFlags(flags)
+	settings := addConfigFlags(flags)
+	return func(args []string) {
+		logs.setup()
+		cfg := settings.load()
+
+		if *outDir == "" {
+			fatal("--out is required")
+		}
+
+		client, err := newClient(cfg.Client)
+		if err != nil {
+			fatalErr(err)
+		}
+
+		if *to == 0 {
+			if *to, err = crawler.DefaultTo(*outDir, client.GetMaxItemId); err != nil {
+				fatalErr(err)
+			}
+		}
+
+		c, err := crawler.NewCrawler(client, *outDir, *workers, *shardSize)
+		if err != nil {
+			fatalErr(err)
+		}
+		c.ReportInterval = *report
+		c.OnProgress = func(stats crawler.Stats) {
+			fmt.Fprintf(os.Stdout, "Crawled up to %d of %d, %.1f items/s\n", stats.Next-1, *to, stats.Rate())
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		fmt.Fprintf(os.Stdout, "Crawling items %d to %d into %s\n", *from, *to, *outDir)
+		stats, err := c.Run(ctx, *from, *to)
+		fmt.Fprintf(os.Stdout, "Fetched %d, missing %d, failed %d in %s (%.1f items/s)\n",
+			stats.Fetched, stats.Missing, stats.Failed, stats.Elapsed.Round(time.Second), stats.Rate())
+
+		if err == context.Canceled {
+			fmt.Fprintf(os.Stdout, "Interrupted before item %d. Run the same command again to resume\n", stats.Next)
+			os.Exit(1)
+		}
+		if err != nil {
+			fatalErr(err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package crawler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const CHECKPOINT_FILE = "checkpoint.json"
+
+// Records how far a crawl has got.
+// Every item before Next has been written to a shard or to the failed file,
+// and nothing after Offset in Shard or after FailedOffset in the failed file has been committed.
+type checkpoint struct {
+	From         int    `json:"from"`
+	To           int    `json:"to"`
+	Next         int    `json:"next"`
+	Shard        string `json:"shard"`
+	Offset       int64  `json:"offset"`
+	FailedOffset int64  `json:"failedOffset"`
+}
+
+// Loads the checkpoint from dir.
+// Returns nil without error if the directory has no checkpoint yet
+func loadCheckpoint(dir string) (*checkpoint, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, CHECKPOINT_FILE))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cp := &checkpoint{}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// Returns the last id to crawl into dir when none is given.
+// A crawl saved in dir keeps its own last id so it can resume even after newer items are posted,
+// otherwise newest is called for the newest item id
+func DefaultTo(dir string, newest func() (int, error)) (int, error) {
+	cp, err := loadCheckpoint(dir)
+	if err != nil {
+		return 0, err
+	}
+	if cp != nil {
+		return cp.To, nil
+	}
+	return newest()
+}
+
+// Writes the checkpoint into dir.
+// Writes to a temporary file first and renames it so a crash never leaves a half written checkpoint
+func (cp checkpoint) save(dir string) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(dir, CHECKPOINT_FILE+".tmp")
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, CHECKPOINT_FILE))
+}
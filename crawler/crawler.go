@@ -0,0 +1,297 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+const (
+	// How often progress is saved while crawling.
+	CHECKPOINT_INTERVAL = 5 * time.Second
+	// How many ids each worker may fetch ahead of the oldest unwritten id.
+	WINDOW_PER_WORKER = 4
+)
+
+// Anything that can retrieve a single item by id. *hackernews.Client and every hackernews.Source satisfy this.
+type ItemGetter interface {
+	Item(ctx context.Context, id int) (*hackernews.RawItem, error)
+}
+
+// Fetches every item in a range of ids and stores them as sharded NDJSON files.
+// Items are written in id order so an interrupted crawl can resume from its checkpoint.
+type Crawler struct {
+	getter    ItemGetter
+	outDir    string
+	workers   int
+	shardSize int
+
+	// Called with the running totals every ReportInterval. Optional.
+	OnProgress     func(Stats)
+	ReportInterval time.Duration
+}
+
+// Throughput and totals of a crawl run
+type Stats struct {
+	Fetched int           `json:"fetched"`
+	Missing int           `json:"missing"`
+	Failed  int           `json:"failed"`
+	Next    int           `json:"next"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// The result of fetching one id
+type result struct {
+	id   int
+	item *hackernews.RawItem
+	err  error
+}
+
+// A failed fetch as stored in the failed file
+type failure struct {
+	ID    int    `json:"id"`
+	Error string `json:"error"`
+}
+
+// Creates a crawler writing into outDir using the given number of workers.
+// shardSize is how many consecutive ids are stored in each shard file.
+func NewCrawler(getter ItemGetter, outDir string, workers, shardSize int) (*Crawler, error) {
+	if workers <= 0 {
+		return nil, InvalidWorkersErr
+	}
+	if shardSize <= 0 {
+		return nil, InvalidShardErr
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &Crawler{
+		getter:         getter,
+		outDir:         outDir,
+		workers:        workers,
+		shardSize:      shardSize,
+		ReportInterval: 10 * time.Second,
+	}, nil
+}
+
+// Returns how many items were processed per second
+func (s Stats) Rate() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Fetched+s.Missing+s.Failed) / s.Elapsed.Seconds()
+}
+
+// Returns true if every id up to and including to has been processed
+func (s Stats) Done(to int) bool {
+	return s.Next > to
+}
+
+// Crawls every item from from to to inclusive.
+// If outDir already has a checkpoint for the same range then the crawl resumes from it.
+// When ctx is cancelled the crawl stops, saves its checkpoint and returns ctx.Err()
+func (c *Crawler) Run(ctx context.Context, from, to int) (Stats, error) {
+	if from <= 0 || to < from {
+		return Stats{}, InvalidRangeErr
+	}
+
+	cp, err := loadCheckpoint(c.outDir)
+	if err != nil {
+		return Stats{}, err
+	}
+	if cp == nil {
+		cp = &checkpoint{From: from, To: to, Next: from}
+	}
+	if cp.From != from || cp.To != to {
+		return Stats{}, &CheckpointMismatchErr{from, to, cp.From, cp.To}
+	}
+
+	stats := Stats{Next: cp.Next}
+	if stats.Done(to) {
+		return stats, nil
+	}
+
+	failed, err := openAppendFile(filepath.Join(c.outDir, FAILED_FILE), cp.FailedOffset)
+	if err != nil {
+		return stats, err
+	}
+	defer failed.close()
+
+	var shard *appendFile
+	if cp.Shard != "" {
+		if shard, err = openAppendFile(filepath.Join(c.outDir, cp.Shard), cp.Offset); err != nil {
+			return stats, err
+		}
+	}
+	defer func() {
+		if shard != nil {
+			shard.close()
+		}
+	}()
+
+	// saves the current position so a later run can resume from it
+	save := func() error {
+		if err := failed.sync(); err != nil {
+			return err
+		}
+		cp.FailedOffset = failed.offset
+		if shard != nil {
+			if err := shard.sync(); err != nil {
+				return err
+			}
+			cp.Shard, cp.Offset = shard.name, shard.offset
+		}
+		cp.Next = stats.Next
+		return cp.save(c.outDir)
+	}
+
+	// writes a single result into the shard for its id
+	write := func(r result) error {
+		// deleted or never created ids come back as null
+		var missing *hackernews.MissingItemErr
+		if errors.As(r.err, &missing) {
+			stats.Missing++
+			return nil
+		}
+		if r.err != nil {
+			stats.Failed++
+			line, _ := json.Marshal(failure{r.id, r.err.Error()})
+			return failed.writeLine(line)
+		}
+		if r.item == nil || r.item.ID == 0 {
+			stats.Missing++
+			return nil
+		}
+
+		if name := shardName(r.id, c.shardSize); shard == nil || shard.name != name {
+			if shard != nil {
+				if err := shard.close(); err != nil {
+					return err
+				}
+			}
+			next, err := openAppendFile(filepath.Join(c.outDir, name), 0)
+			if err != nil {
+				return err
+			}
+			shard = next
+			// checkpoint on every new shard so uncommitted items only ever live in one file
+			if err := save(); err != nil {
+				return err
+			}
+		}
+
+		line, err := json.Marshal(r.item)
+		if err != nil {
+			return err
+		}
+		stats.Fetched++
+		return shard.writeLine(line)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ids := make(chan int)
+	results := make(chan result, c.workers)
+	window := make(chan struct{}, c.workers*WINDOW_PER_WORKER)
+
+	// dispatch ids in order, never getting more than the window ahead of the writer
+	go func() {
+		defer close(ids)
+		for id := cp.Next; id <= to; id++ {
+			select {
+			case window <- struct{}{}:
+			case <-runCtx.Done():
+				return
+			}
+			select {
+			case ids <- id:
+			case <-runCtx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range ids {
+				item, err := c.getter.Item(runCtx, id)
+				if err != nil && runCtx.Err() != nil {
+					// cut short by the cancel, so leave the id for the next run instead of recording it as failed
+					continue
+				}
+				results <- result{id, item, err}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	start := time.Now()
+	lastSave, lastReport := start, start
+	pending := make(map[int]result)
+	var writeErr error
+
+	// collect results and write them in id order
+	for r := range results {
+		if writeErr != nil {
+			continue // drain remaining results
+		}
+		pending[r.id] = r
+		for {
+			next, ok := pending[stats.Next]
+			if !ok {
+				break
+			}
+			delete(pending, stats.Next)
+			if writeErr = write(next); writeErr != nil {
+				cancel()
+				break
+			}
+			stats.Next++
+			<-window
+		}
+		if writeErr != nil {
+			continue
+		}
+
+		now := time.Now()
+		if now.Sub(lastSave) >= CHECKPOINT_INTERVAL {
+			if writeErr = save(); writeErr != nil {
+				cancel()
+				continue
+			}
+			lastSave = now
+		}
+		if c.OnProgress != nil && c.ReportInterval > 0 && now.Sub(lastReport) >= c.ReportInterval {
+			stats.Elapsed = now.Sub(start)
+			c.OnProgress(stats)
+			lastReport = now
+		}
+	}
+
+	stats.Elapsed = time.Since(start)
+	if writeErr != nil {
+		return stats, writeErr
+	}
+	if err := save(); err != nil {
+		return stats, err
+	}
+	if !stats.Done(to) {
+		return stats, ctx.Err()
+	}
+	return stats, nil
+}
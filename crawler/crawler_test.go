@@ -0,0 +1,281 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/alis93/hn-scraper/hntest"
+)
+
+// Fake item getter. Every 7th id is missing and id 13 always fails.
+// Calls onGet, if set, before returning each item, and fails with ctx.Err() once ctx is cancelled.
+type fakeGetter struct {
+	mu    sync.Mutex
+	calls int
+	onGet func(calls int)
+}
+
+func (g *fakeGetter) Item(ctx context.Context, id int) (*hackernews.RawItem, error) {
+	g.mu.Lock()
+	g.calls++
+	calls := g.calls
+	g.mu.Unlock()
+	if g.onGet != nil {
+		g.onGet(calls)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if id == 13 {
+		return nil, fmt.Errorf("failed to get %d", id)
+	}
+	if id%7 == 0 {
+		return &hackernews.RawItem{}, nil
+	}
+	return &hackernews.RawItem{ID: id, ItemType: "story", Title: fmt.Sprintf("item %d", id)}, nil
+}
+
+// Reads every item id written to the shards in dir, in file then line order
+func readShardIds(t *testing.T, dir string) []int {
+	paths, err := filepath.Glob(filepath.Join(dir, "items-*.ndjson"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(paths)
+
+	ids := []int{}
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			item := &hackernews.RawItem{}
+			if err := json.Unmarshal(scanner.Bytes(), item); err != nil {
+				t.Fatalf("Shard %s has an invalid line. \n Reason : %s", path, err.Error())
+			}
+			ids = append(ids, item.ID)
+		}
+		file.Close()
+	}
+	return ids
+}
+
+// Returns the ids the fake getter should have stored between from and to
+func expectedIds(from, to int) []int {
+	ids := []int{}
+	for id := from; id <= to; id++ {
+		if id != 13 && id%7 != 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "crawl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestNewCrawler(t *testing.T) {
+	log.Println("Testing crawler creation")
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	if _, err := NewCrawler(&fakeGetter{}, dir, 0, 10); err != InvalidWorkersErr {
+		t.Errorf("Expected error was incorrect. \n\t Expected %v got %v", InvalidWorkersErr, err)
+	}
+	if _, err := NewCrawler(&fakeGetter{}, dir, 4, 0); err != InvalidShardErr {
+		t.Errorf("Expected error was incorrect. \n\t Expected %v got %v", InvalidShardErr, err)
+	}
+
+	crawler, err := NewCrawler(&fakeGetter{}, dir, 4, 10)
+	if err != nil {
+		t.Fatalf("Failed to create crawler. Reason : %s", err.Error())
+	}
+	if _, err := crawler.Run(context.Background(), 0, 10); err != InvalidRangeErr {
+		t.Errorf("Expected error was incorrect. \n\t Expected %v got %v", InvalidRangeErr, err)
+	}
+	if _, err := crawler.Run(context.Background(), 20, 10); err != InvalidRangeErr {
+		t.Errorf("Expected error was incorrect. \n\t Expected %v got %v", InvalidRangeErr, err)
+	}
+}
+
+func TestCrawlerRun(t *testing.T) {
+	log.Println("Testing crawling a full range")
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	from, to := 1, 95
+	crawler, err := NewCrawler(&fakeGetter{}, dir, 8, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := crawler.Run(context.Background(), from, to)
+	if err != nil {
+		t.Fatalf("Crawl failed. Reason : %s", err.Error())
+	}
+
+	expected := expectedIds(from, to)
+	if stats.Fetched != len(expected) || stats.Failed != 1 || stats.Missing != 13 {
+		t.Errorf("Stats were incorrect. \n\t Expected fetched %d failed 1 missing 13 Actual %+v", len(expected), stats)
+	}
+
+	ids := readShardIds(t, dir)
+	if fmt.Sprint(ids) != fmt.Sprint(expected) {
+		t.Errorf("Stored ids were incorrect. \n\t Expected %v \n\t Actual %v", expected, ids)
+	}
+
+	paths, _ := filepath.Glob(filepath.Join(dir, "items-*.ndjson"))
+	if len(paths) != 5 {
+		t.Errorf("Expected 5 shards but got %d", len(paths))
+	}
+
+	failed, err := ioutil.ReadFile(filepath.Join(dir, FAILED_FILE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(failed) != "{\"id\":13,\"error\":\"failed to get 13\"}\n" {
+		t.Errorf("Failed file was incorrect. Actual %s", failed)
+	}
+
+	// running again with a complete checkpoint does nothing
+	stats, err = crawler.Run(context.Background(), from, to)
+	if err != nil || stats.Fetched != 0 || !stats.Done(to) {
+		t.Errorf("Expected completed crawl to be a no-op, got %+v %v", stats, err)
+	}
+
+	if _, err := crawler.Run(context.Background(), from, to+1); err == nil {
+		t.Errorf("Expected an error when resuming with a different range")
+	}
+}
+
+func TestCrawlerResume(t *testing.T) {
+	log.Println("Testing resuming an interrupted crawl")
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	from, to := 1, 250
+
+	// interrupt the crawl part way through
+	ctx, cancel := context.WithCancel(context.Background())
+	getter := &fakeGetter{onGet: func(calls int) {
+		if calls == 120 {
+			cancel()
+		}
+	}}
+	crawler, err := NewCrawler(getter, dir, 6, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := crawler.Run(ctx, from, to)
+	if err != context.Canceled {
+		t.Fatalf("Expected crawl to be cancelled but got %v", err)
+	}
+	if stats.Done(to) {
+		t.Fatalf("Crawl should not have finished, stopped at %d", stats.Next)
+	}
+
+	crawler, err = NewCrawler(&fakeGetter{}, dir, 3, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed, err := crawler.Run(context.Background(), from, to)
+	if err != nil {
+		t.Fatalf("Resumed crawl failed. Reason : %s", err.Error())
+	}
+	if !resumed.Done(to) {
+		t.Fatalf("Resumed crawl did not finish, stopped at %d", resumed.Next)
+	}
+
+	expected := expectedIds(from, to)
+	if stats.Fetched+resumed.Fetched != len(expected) {
+		t.Errorf("Items fetched across both runs was incorrect. \n\t Expected %d Actual %d", len(expected), stats.Fetched+resumed.Fetched)
+	}
+
+	// every item is stored exactly once and in order
+	ids := readShardIds(t, dir)
+	if fmt.Sprint(ids) != fmt.Sprint(expected) {
+		t.Errorf("Stored ids were incorrect. \n\t Expected %v \n\t Actual %v", expected, ids)
+	}
+}
+
+// Gets items from the client, cancelling after the given number of items
+type interruptingGetter struct {
+	*hackernews.Client
+	after  int32
+	cancel func()
+	calls  int32
+}
+
+func (g *interruptingGetter) Item(ctx context.Context, id int) (*hackernews.RawItem, error) {
+	if atomic.AddInt32(&g.calls, 1) == g.after {
+		g.cancel()
+	}
+	return g.Client.Item(ctx, id)
+}
+
+func TestCrawlerResumeAfterNewItems(t *testing.T) {
+	log.Println("Testing resuming a crawl without an end after new items are posted")
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	t.Setenv(hntest.RECORD_ENV, "")
+	api := hntest.NewServer(t, filepath.Join("..", "hackernews", "testdata"))
+	api.SetMaxItem(60)
+	client, err := hackernews.NewClient(5, hackernews.WithAPIURL(api.APIURL()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	to, err := DefaultTo(dir, client.GetMaxItemId)
+	if err != nil || to != 60 {
+		t.Fatalf("Expected a fresh crawl to end at the newest item 60, got %d %v", to, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	crawler, err := NewCrawler(&interruptingGetter{Client: client, after: 20, cancel: cancel}, dir, 2, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := crawler.Run(ctx, 1, to); err != context.Canceled {
+		t.Fatalf("Expected crawl to be cancelled but got %v", err)
+	}
+
+	// new items were posted while the crawl was stopped
+	api.SetMaxItem(90)
+	resumeTo, err := DefaultTo(dir, client.GetMaxItemId)
+	if err != nil || resumeTo != to {
+		t.Fatalf("Expected the resumed crawl to keep its end of %d, got %d %v", to, resumeTo, err)
+	}
+
+	crawler, err = NewCrawler(client, dir, 2, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := crawler.Run(context.Background(), 1, resumeTo)
+	if err != nil {
+		t.Fatalf("Resumed crawl failed. Reason : %s", err.Error())
+	}
+	if !stats.Done(to) {
+		t.Errorf("Resumed crawl did not finish, stopped at %d", stats.Next)
+	}
+}
@@ -0,0 +1,40 @@
+package crawler
+
+import "fmt"
+
+var (
+	InvalidRangeErr   = fmt.Errorf("Crawl range is invalid. from must be at least 1 and not more than to")
+	InvalidWorkersErr = fmt.Errorf("Number of workers must be more than 0")
+	InvalidShardErr   = fmt.Errorf("Shard size must be more than 0")
+)
+
+type CheckpointMismatchErr struct {
+	from, to                 int
+	expectedFrom, expectedTo int
+}
+
+func (e *CheckpointMismatchErr) Error() string {
+	return fmt.Sprintf("Output directory has a checkpoint for items %d to %d, but %d to %d was requested. Use the same range to resume or a new directory", e.expectedFrom, e.expectedTo, e.from, e.to)
+}
+
+type NoSnapshotErr struct {
+	dir string
+}
+
+func (e *NoSnapshotErr) Error() string {
+	return fmt.Sprintf("%s has no crawled items. Run crawl with --out %s first", e.dir, e.dir)
+}
+
+type ShardErr struct {
+	shard string
+	line  int
+	err   error
+}
+
+func (e *ShardErr) Error() string {
+	return fmt.Sprintf("Line %d of shard %s is not a valid item. \t %s", e.line, e.shard, e.err.Error())
+}
+
+func (e *ShardErr) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,55 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+// Calls visit with every item stored in the shards of a crawl output directory, in id order.
+// Stops and returns the error if visit returns one.
+// Returns *NoSnapshotErr if dir has no shards
+func ReadSnapshot(dir string, visit func(*hackernews.RawItem) error) error {
+	paths, err := filepath.Glob(filepath.Join(dir, strings.Replace(SHARD_TEMPLATE, "%09d", "*", 1)))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return &NoSnapshotErr{dir}
+	}
+	// shard numbers are zero padded, so name order is id order
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if err := readShard(path, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readShard(path string, visit func(*hackernews.RawItem) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), hackernews.MAX_LINE_BYTES)
+	for line := 1; scanner.Scan(); line++ {
+		item := &hackernews.RawItem{}
+		if err := json.Unmarshal(scanner.Bytes(), item); err != nil {
+			return &ShardErr{filepath.Base(path), line, err}
+		}
+		if err := visit(item); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,56 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+func TestReadSnapshot(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	crawler, _ := NewCrawler(&fakeGetter{}, dir, 4, 10)
+	if _, err := crawler.Run(context.Background(), 1, 45); err != nil {
+		t.Fatalf("Crawl failed. Reason : %s", err.Error())
+	}
+
+	ids := []int{}
+	err := ReadSnapshot(dir, func(item *hackernews.RawItem) error {
+		ids = append(ids, item.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to read snapshot. Reason : %s", err.Error())
+	}
+	if expected := expectedIds(1, 45); fmt.Sprint(ids) != fmt.Sprint(expected) {
+		t.Errorf("Read ids were incorrect. \n\t Expected %v \n\t Actual %v", expected, ids)
+	}
+
+	// visit errors stop reading
+	stop := fmt.Errorf("stop")
+	if err := ReadSnapshot(dir, func(*hackernews.RawItem) error { return stop }); err != stop {
+		t.Errorf("Expected the visit error but got %v", err)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "items-000000009.ndjson"), []byte("{\"id\": 90}\nnot json\n"), 0644)
+	if err := ReadSnapshot(dir, func(*hackernews.RawItem) error { return nil }); err == nil {
+		t.Errorf("Expected an invalid shard line to fail")
+	} else if _, ok := err.(*ShardErr); !ok {
+		t.Errorf("Expected *ShardErr but got %v", err)
+	}
+}
+
+func TestReadSnapshotEmpty(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	if _, ok := ReadSnapshot(dir, nil).(*NoSnapshotErr); !ok {
+		t.Errorf("Expected *NoSnapshotErr for an empty directory")
+	}
+}
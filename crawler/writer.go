@@ -0,0 +1,81 @@
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	SHARD_TEMPLATE = "items-%09d.ndjson"
+	FAILED_FILE    = "failed.ndjson"
+)
+
+// Returns the name of the shard file an item id belongs to.
+// Each shard holds shardSize consecutive ids.
+func shardName(id, shardSize int) string {
+	return fmt.Sprintf(SHARD_TEMPLATE, id/shardSize)
+}
+
+// An append only file that keeps track of how many bytes it holds.
+type appendFile struct {
+	name   string
+	file   *os.File
+	buf    *bufio.Writer
+	offset int64
+}
+
+// Opens the file for appending, truncating it to offset first.
+// Anything past offset was written after the last checkpoint and will be written again.
+func openAppendFile(path string, offset int64) (*appendFile, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Truncate(offset); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Seek(offset, 0); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &appendFile{
+		name:   filepath.Base(path),
+		file:   file,
+		buf:    bufio.NewWriter(file),
+		offset: offset,
+	}, nil
+}
+
+// Writes a single line to the file
+func (f *appendFile) writeLine(line []byte) error {
+	n, err := f.buf.Write(line)
+	f.offset += int64(n)
+	if err != nil {
+		return err
+	}
+	if err := f.buf.WriteByte('\n'); err != nil {
+		return err
+	}
+	f.offset++
+	return nil
+}
+
+// Flushes buffered lines and syncs them to disk
+func (f *appendFile) sync() error {
+	if err := f.buf.Flush(); err != nil {
+		return err
+	}
+	return f.file.Sync()
+}
+
+func (f *appendFile) close() error {
+	if err := f.sync(); err != nil {
+		f.file.Close()
+		return err
+	}
+	return f.file.Close()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/alis93/hn-scraper/digest"
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+// Emails the top stories of a list, or saves the email to a file with --dry-run.
+func defineDigest(flags *flag.FlagSet) func(args []string) {
+	list := flags.String("list", "top", "Story list to scrape. One of top, new, best, ask, show or job")
+	n := flags.Int("n", 10, "How many stories to include")
+	posts := flags.Int("posts", 30, "How many stories of the list to consider before filtering")
+	minPoints := flags.Int("min-points", 0, "Leave out stories with fewer points")
+	minComments := flags.Int("min-comments", 0, "Leave out stories with fewer comments")
+	dedupe := flags.Bool("dedupe", false, "Group submissions of the same article, keeping the highest ranked")
+	dryRun := flags.String("dry-run", "", "Write the email as a .eml file into this directory instead of sending it")
+	logs := addLogFlags(flags)
+	settings := addConfigFlags(flags)
+	sources := addSourceFlags(flags)
+	return func(args []string) {
+		logs.setup()
+		cfg := settings.load()
+
+		if *n <= 0 {
+			fatal("--n must be more than 0", "n", *n)
+		}
+		if err := cfg.Digest.Validate(); err != nil {
+			fatalErr(err)
+		}
+		if password := os.Getenv("HN_SMTP_PASSWORD"); password != "" {
+			cfg.Digest.SMTP.Password = password
+		}
+
+		source := sources.open(cfg)
+		converter, err := newConverter(cfg.Converter)
+		if err != nil {
+			fatalErr(err)
+		}
+
+		stories, err := fetchStories(source, converter, *list, *posts, nil)
+		if err != nil {
+			fatalErr(err)
+		}
+		sources.close()
+		if *dedupe {
+			stories = hackernews.Dedupe(stories, hackernews.DUPLICATE_TITLE_SIMILARITY)
+		}
+		stories = digest.Filter(stories, *n, *minPoints, *minComments)
+
+		now := time.Now()
+		subject := fmt.Sprintf("%s, %s", cfg.Digest.Subject, now.Format("2 Jan 2006"))
+		html, text, err := digest.Render(digest.Digest{Subject: subject, List: *list, Date: now, Stories: stories})
+		if err != nil {
+			fatalErr(err)
+		}
+		msg := digest.Message{
+			From:    cfg.Digest.From,
+			To:      cfg.Digest.To,
+			Subject: subject,
+			Date:    now,
+			HTML:    html,
+			Text:    text,
+		}
+
+		if *dryRun != "" {
+			path, err := digest.WriteEML(*dryRun, msg)
+			if err != nil {
+				fatalErr(err)
+			}
+			fmt.Fprintf(os.Stdout, "Wrote digest of %d stories to %s\n", len(stories), path)
+			return
+		}
+
+		if err := digest.Send(cfg.Digest.SMTP, msg); err != nil {
+			fatalErr(err)
+		}
+		fmt.Fprintf(os.Stdout, "Sent digest of %d stories to %d recipients\n", len(stories), len(msg.To))
+	}
+}
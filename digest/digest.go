@@ -0,0 +1,56 @@
+package digest
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+var (
+	htmlTemplate = htmltemplate.Must(htmltemplate.ParseFS(templateFiles, "templates/digest.html"))
+	textTemplate = texttemplate.Must(texttemplate.New("digest.txt").
+			Funcs(texttemplate.FuncMap{"inc": func(i int) int { return i + 1 }}).
+			ParseFS(templateFiles, "templates/digest.txt"))
+)
+
+// The stories of one digest email
+type Digest struct {
+	Subject string
+	List    string
+	Date    time.Time
+	Stories []*hackernews.Story
+}
+
+// Renders the digest as the html and plain text bodies of an email
+func Render(d Digest) (html, text string, err error) {
+	var htmlBody, textBody bytes.Buffer
+	if err := htmlTemplate.Execute(&htmlBody, d); err != nil {
+		return "", "", err
+	}
+	if err := textTemplate.Execute(&textBody, d); err != nil {
+		return "", "", err
+	}
+	return htmlBody.String(), textBody.String(), nil
+}
+
+// Returns the stories with at least minPoints points and minComments comments, keeping at most n.
+// No stories are kept if n is 0 or less
+func Filter(stories []*hackernews.Story, n, minPoints, minComments int) []*hackernews.Story {
+	filtered := []*hackernews.Story{}
+	for _, story := range stories {
+		if len(filtered) >= n {
+			break
+		}
+		if story != nil && story.Points >= minPoints && story.Comments >= minComments {
+			filtered = append(filtered, story)
+		}
+	}
+	return filtered
+}
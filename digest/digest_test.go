@@ -0,0 +1,276 @@
+package digest
+
+import (
+	"bufio"
+	"io/ioutil"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+var testStories = []*hackernews.Story{
+	{ID: 1, Title: "Google’s robots.txt parser is now open source", URL: "https://opensource.googleblog.com/post", Domain: "googleblog.com", Author: "dankohn1", Points: 200, Comments: 80},
+	{ID: 2, Title: "<script>alert(1)</script> & friends", URL: "https://example.com/xss?a=1&b=2", Domain: "example.com", Author: "mallory", Points: 50, Comments: 3},
+	{ID: 3, Title: "Quiet story", URL: "https://example.org/", Author: "someone", Points: 2, Comments: 0},
+	nil,
+}
+
+var testDate = time.Date(2019, 7, 1, 8, 0, 0, 0, time.UTC)
+
+func TestRender(t *testing.T) {
+	log.Println("Testing rendering digests")
+
+	html, text, err := Render(Digest{Subject: "Daily digest", List: "top", Date: testDate, Stories: testStories[:2]})
+	if err != nil {
+		t.Fatalf("Failed to render digest. Reason : %s", err.Error())
+	}
+
+	htmlContains := []string{
+		"The top 2 top stories on Hacker News, Monday 1 July 2019.",
+		`<a href="https://opensource.googleblog.com/post"`,
+		"Google’s robots.txt parser is now open source</a> <span style=\"color: #828282;\">(googleblog.com)</span>",
+		"&lt;script&gt;alert(1)&lt;/script&gt; &amp; friends",
+		`href="https://example.com/xss?a=1&amp;b=2"`,
+		`https://news.ycombinator.com/item?id=2`,
+	}
+	for _, expected := range htmlContains {
+		if !strings.Contains(html, expected) {
+			t.Errorf("Html should contain %q. \n%s", expected, html)
+		}
+	}
+	if strings.Contains(html, "<script>") {
+		t.Errorf("Titles must be escaped in html")
+	}
+
+	expectedText := "1. Google’s robots.txt parser is now open source (googleblog.com)\n" +
+		"   https://opensource.googleblog.com/post\n" +
+		"   200 points by dankohn1 | 80 comments: https://news.ycombinator.com/item?id=1\n\n" +
+		"2. <script>alert(1)</script> & friends (example.com)\n"
+	if !strings.Contains(text, expectedText) {
+		t.Errorf("Text incorrect. \n\t Expected to contain %q \n\t Actual %q", expectedText, text)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	filterTests := []struct {
+		n, minPoints, minComments int
+		expected                  []int
+	}{
+		{10, 0, 0, []int{1, 2, 3}},
+		{2, 0, 0, []int{1, 2}},
+		{10, 10, 0, []int{1, 2}},
+		{10, 0, 10, []int{1}},
+		{0, 0, 0, []int{}},
+		{-1, 0, 0, []int{}},
+	}
+	for _, test := range filterTests {
+		ids := []int{}
+		for _, story := range Filter(testStories, test.n, test.minPoints, test.minComments) {
+			ids = append(ids, story.ID)
+		}
+		if len(ids) != len(test.expected) || (len(ids) > 0 && ids[len(ids)-1] != test.expected[len(test.expected)-1]) {
+			t.Errorf("Filter %+v incorrect. Actual %v", test, ids)
+		}
+	}
+}
+
+// Parses an email and returns its subject and the decoded body of each part by content type
+func parseMessage(t *testing.T, data string) (string, map[string]string) {
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Message is not a valid email. Reason : %s", err.Error())
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+
+	mediaType, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("Expected multipart/alternative but got %s", mediaType)
+	}
+	parts := make(map[string]string)
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart() // decodes quoted printable
+		if err != nil {
+			break
+		}
+		body, _ := ioutil.ReadAll(part)
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[contentType] = strings.ReplaceAll(string(body), "\r\n", "\n")
+	}
+	return subject, parts
+}
+
+func testMessage() Message {
+	return Message{
+		From:    "HN Digest <digest@example.com>",
+		To:      []string{"team@example.com", "Bob <bob@example.com>"},
+		Subject: "Digest – Monday",
+		Date:    testDate,
+		HTML:    "<p>Café " + strings.Repeat("long line ", 20) + "</p>",
+		Text:    "Café\nsecond line",
+	}
+}
+
+func TestMessageBytes(t *testing.T) {
+	log.Println("Testing building digest emails")
+
+	msg := testMessage()
+	data, err := msg.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, parts := parseMessage(t, string(data))
+	if subject != msg.Subject {
+		t.Errorf("Subject incorrect. \n\t Expected %q Actual %q", msg.Subject, subject)
+	}
+	if parts["text/plain"] != msg.Text || parts["text/html"] != msg.HTML {
+		t.Errorf("Parts incorrect. \n\t Actual %q", parts)
+	}
+	for _, line := range strings.Split(string(data), "\r\n") {
+		if len(line) > 78 && !strings.HasPrefix(line, "Content-Type") {
+			t.Errorf("Line longer than 78 characters: %q", line)
+		}
+	}
+}
+
+// A minimal SMTP server accepting one message, without STARTTLS or auth.
+// Returns its host and port and a channel receiving the envelope and message
+func fakeSMTPServer(t *testing.T) (string, int, chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan string, 1)
+
+	go func() {
+		defer listener.Close()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		reply("220 localhost ESMTP")
+		var envelope []string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch command {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "MAIL", "RCPT":
+				envelope = append(envelope, line)
+				reply("250 OK")
+			case "DATA":
+				reply("354 Go ahead")
+				var data strings.Builder
+				for {
+					dataLine, err := r.ReadString('\n')
+					if err != nil || dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(dataLine)
+				}
+				received <- strings.Join(envelope, "\n") + "\n\n" + data.String()
+				reply("250 Queued")
+			case "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("502 Not implemented")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return host, portNumber, received
+}
+
+func TestSend(t *testing.T) {
+	log.Println("Testing sending digests over SMTP")
+
+	host, port, received := fakeSMTPServer(t)
+	cfg := SMTPConfig{Host: host, Port: port, Timeout: 5}
+	if err := Send(cfg, testMessage()); err != nil {
+		t.Fatalf("Failed to send digest. Reason : %s", err.Error())
+	}
+
+	select {
+	case data := <-received:
+		envelope := strings.SplitN(data, "\n\n", 2)[0]
+		expected := "MAIL FROM:<digest@example.com>\nRCPT TO:<team@example.com>\nRCPT TO:<bob@example.com>"
+		if !strings.HasPrefix(envelope, expected) {
+			t.Errorf("Envelope incorrect. \n\t Expected %q Actual %q", expected, envelope)
+		}
+		if !strings.Contains(data, "Subject: =?utf-8?q?Digest_=E2=80=93_Monday?=") {
+			t.Errorf("Message not sent. \n%s", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server received nothing")
+	}
+}
+
+func TestSendRequiresStartTLS(t *testing.T) {
+	host, port, _ := fakeSMTPServer(t)
+	cfg := SMTPConfig{Host: host, Port: port, StartTLS: true, Timeout: 5}
+	if err := Send(cfg, testMessage()); err != NoStartTLSErr {
+		t.Errorf("Expected NoStartTLSErr but got %v", err)
+	}
+	if err := Send(SMTPConfig{}, testMessage()); err != NoHostErr {
+		t.Errorf("Expected NoHostErr but got %v", err)
+	}
+}
+
+func TestWriteEML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path, err := WriteEML(dir, testMessage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(path, "digest-2019-07-01-080000.eml") {
+		t.Errorf("Unexpected path %s", path)
+	}
+	data, _ := ioutil.ReadFile(path)
+	if _, parts := parseMessage(t, string(data)); parts["text/plain"] != testMessage().Text {
+		t.Errorf("Saved message incorrect. %q", parts)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	validateTests := []struct {
+		cfg      Config
+		expected bool
+	}{
+		{Config{From: "digest@example.com", To: []string{"team@example.com"}}, true},
+		{Config{To: []string{"team@example.com"}}, false},
+		{Config{From: "digest@example.com"}, false},
+		{Config{From: "digest@example.com", To: []string{"not an address"}}, false},
+	}
+	for _, test := range validateTests {
+		if err := test.cfg.Validate(); (err == nil) != test.expected {
+			t.Errorf("%+v valid should be %t but got %v", test.cfg, test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package digest
+
+import "fmt"
+
+var (
+	NoHostErr       = fmt.Errorf("SMTP host is required to send the digest")
+	NoFromErr       = fmt.Errorf("Digest needs a from address")
+	NoRecipientsErr = fmt.Errorf("Digest needs at least one recipient")
+	NoStartTLSErr   = fmt.Errorf("SMTP server does not support STARTTLS. Set startTLS to false to send without encryption")
+)
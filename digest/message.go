@@ -0,0 +1,83 @@
+package digest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// An email with html and plain text versions of the same body
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Date    time.Time
+	HTML    string
+	Text    string
+}
+
+// Returns the message in RFC 5322 format as a multipart/alternative email, ready to send or save as a .eml file
+func (m Message) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	body := multipart.NewWriter(&buf)
+
+	headers := []string{
+		"From: " + m.From,
+		"To: " + strings.Join(m.To, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", m.Subject),
+		"Date: " + m.Date.Format(time.RFC1123Z),
+		"Message-ID: " + messageID(m.From),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + body.Boundary(),
+	}
+	message := bytes.NewBufferString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	// plain text first, as clients show the last part they understand
+	if err := writePart(body, "text/plain; charset=utf-8", m.Text); err != nil {
+		return nil, err
+	}
+	if err := writePart(body, "text/html; charset=utf-8", m.HTML); err != nil {
+		return nil, err
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+
+	message.Write(buf.Bytes())
+	return message.Bytes(), nil
+}
+
+// Writes content as a quoted printable part, so long lines and non ascii characters survive any mail server
+func writePart(body *multipart.Writer, contentType, content string) error {
+	part, err := body.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	encoder := quotedprintable.NewWriter(part)
+	if _, err := io.WriteString(encoder, strings.ReplaceAll(content, "\n", "\r\n")); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// Returns a unique Message-ID using the domain of the from address
+func messageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = strings.Trim(from[at+1:], "> ")
+	}
+	random := make([]byte, 12)
+	rand.Read(random)
+	return fmt.Sprintf("<%s.%s@%s>", time.Now().UTC().Format("20060102150405"), hex.EncodeToString(random), domain)
+}
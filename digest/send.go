@@ -0,0 +1,135 @@
+package digest
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Where and how to send the digest, loadable from the config file
+type Config struct {
+	From    string     `json:"from" yaml:"from" toml:"from"`
+	To      []string   `json:"to" yaml:"to" toml:"to"`
+	Subject string     `json:"subject" yaml:"subject" toml:"subject"`
+	SMTP    SMTPConfig `json:"smtp" yaml:"smtp" toml:"smtp"`
+}
+
+// The mail server to send through
+type SMTPConfig struct {
+	Host string `json:"host" yaml:"host" toml:"host"`
+	Port int    `json:"port" yaml:"port" toml:"port"`
+	// Logs in with PLAIN auth if set. Requires STARTTLS unless the server is localhost
+	Username string `json:"username" yaml:"username" toml:"username"`
+	Password string `json:"password" yaml:"password" toml:"password"`
+	// Refuses to send if the server does not support STARTTLS. STARTTLS is always used when supported
+	StartTLS bool `json:"startTLS" yaml:"startTLS" toml:"startTLS"`
+	// Seconds to wait when connecting
+	Timeout int `json:"timeout" yaml:"timeout" toml:"timeout"`
+}
+
+// Settings used when the config file has none
+var DefaultConfig = Config{
+	Subject: "Hacker News digest",
+	SMTP:    SMTPConfig{Port: 587, StartTLS: true, Timeout: 30},
+}
+
+// Returns error if there is no from address or recipient
+func (cfg Config) Validate() error {
+	if cfg.From == "" {
+		return NoFromErr
+	}
+	if len(cfg.To) == 0 {
+		return NoRecipientsErr
+	}
+	for _, address := range append([]string{cfg.From}, cfg.To...) {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sends the message through the SMTP server, upgrading the connection with STARTTLS when the server supports it
+func Send(cfg SMTPConfig, msg Message) error {
+	if cfg.Host == "" {
+		return NoHostErr
+	}
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)), time.Duration(cfg.Timeout)*time.Second)
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: cfg.Host}); err != nil {
+			return err
+		}
+	} else if cfg.StartTLS {
+		return NoStartTLSErr
+	}
+
+	if cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return err
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		recipient, err := mail.ParseAddress(to)
+		if err != nil {
+			return err
+		}
+		if err := client.Rcpt(recipient.Address); err != nil {
+			return err
+		}
+	}
+
+	body, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := body.Write(data); err != nil {
+		return err
+	}
+	if err := body.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// Writes the message to a .eml file in dir named after its date, for checking a digest without sending it.
+// Returns the path of the file
+func WriteEML(dir string, msg Message) (string, error) {
+	data, err := msg.Bytes()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "digest-"+msg.Date.Format("2006-01-02-150405")+".eml")
+	return path, ioutil.WriteFile(path, data, 0644)
+}
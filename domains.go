@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+// Scrapes a story list and reports which domains the stories come from.
+func defineDomains(flags *flag.FlagSet) func(args []string) {
+	list := flags.String("list", "top", "Story list to scrape. One of top, new, best, ask, show or job")
+	posts := flags.Int("posts", 100, "How many stories of the list to scrape")
+	format := flags.String("format", "text", "Output format. Either text or json")
+	dedupe := flags.Bool("dedupe", false, "Count submissions of the same article once")
+	logs := addLogFlags(flags)
+	settings := addConfigFlags(flags)
+	sources := addSourceFlags(flags)
+	return func(args []string) {
+		logs.setup()
+		cfg := settings.load()
+
+		if *format != "text" && *format != "json" {
+			fatal("invalid --format, must be text or json", "value", *format)
+		}
+
+		source := sources.open(cfg)
+		converter, err := newConverter(cfg.Converter)
+		if err != nil {
+			fatalErr(err)
+		}
+
+		stories, err := fetchStories(source, converter, *list, *posts, nil)
+		if err != nil {
+			fatalErr(err)
+		}
+		sources.close()
+		if *dedupe {
+			stories = hackernews.Dedupe(stories, hackernews.DUPLICATE_TITLE_SIMILARITY)
+		}
+		domains := hackernews.AggregateByDomain(stories)
+
+		if *format == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "    ")
+			encoder.Encode(domains)
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "DOMAIN\tSTORIES\tPOINTS\tMEDIAN COMMENTS\t")
+		for _, domain := range domains {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%.1f\t\n", domain.Domain, domain.Stories, domain.TotalPoints, domain.MedianComments)
+		}
+		w.Flush()
+	}
+}
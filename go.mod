@@ -1,3 +1,11 @@
 module github.com/alis93/hn-scraper
 
-require github.com/google/go-cmp v0.3.0
+go 1.24
+
+require (
+	github.com/BurntSushi/toml v1.4.0
+	github.com/google/go-cmp v0.3.0
+	golang.org/x/net v0.33.0
+	golang.org/x/text v0.21.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -1,10 +1,13 @@
 package hackernews
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -12,60 +15,161 @@ const (
 	BASE_URL             = "https://hacker-news.firebaseio.com"
 	API_VERSION          = "v0"
 	TOP_STORIES_ENDPOINT = "topstories.json"
+	STORIES_ENDPOINT     = "%sstories.json"
 	ITEM_ENDPOINT        = "item/%d.json"
+	USER_ENDPOINT        = "user/%s.json"
+	MAX_ITEM_ENDPOINT    = "maxitem.json"
 )
 
+// The story lists available on hackernews and the most ids each one returns.
+var StoryLists = map[string]int{
+	"top":  500,
+	"new":  500,
+	"best": 500,
+	"ask":  200,
+	"show": 200,
+	"job":  200,
+}
+
 type Client struct {
-	http   *http.Client
-	apiURL string
+	http    *http.Client
+	apiURL  string
+	retry   RetryPolicy
+	metrics *ClientMetrics
+	logger  *slog.Logger
 }
 
-// Creates a client with given timeout
-func NewClient(timeout int) (*Client, error) {
+// Optional settings applied when creating a client
+type ClientOption func(*Client)
+
+// Sets the base url of the api, for example to point the client at a test server.
+func WithAPIURL(apiURL string) ClientOption {
+	return func(c *Client) {
+		c.apiURL = apiURL
+	}
+}
 
-	apiURL := fmt.Sprintf("%s/%s", BASE_URL, API_VERSION)
+// Sets how failed requests are retried. By default requests are not retried.
+func WithRetries(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
 
-	if !isValidURLScheme(apiURL) {
-		return nil, &ClientErr{"API URL is an invalid URL"}
+// Sends requests with rt instead of http.DefaultTransport, for example to record or replay them.
+func WithTransport(rt http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.http.Transport = rt
 	}
+}
+
+// Records requests, latencies and retries in m.
+func WithMetrics(m *ClientMetrics) ClientOption {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+// Logs requests at debug level and retries at warn level to logger.
+// By default nothing is logged.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// Creates a client with given timeout
+func NewClient(timeout int, opts ...ClientOption) (*Client, error) {
 
 	if timeout <= 0 {
 		return nil, InvalidTimeOutErr
 	}
 
-	return &Client{
-		&http.Client{Timeout: time.Duration(timeout) * time.Second},
-		apiURL,
-	}, nil
+	client := &Client{
+		http:   &http.Client{Timeout: time.Duration(timeout) * time.Second},
+		apiURL: fmt.Sprintf("%s/%s", BASE_URL, API_VERSION),
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	if client.metrics != nil {
+		client.http.Transport = client.metrics.Instrument(client.http.Transport)
+	}
+
+	if !isValidURLScheme(client.apiURL) {
+		return nil, &ClientErr{"API URL is an invalid URL"}
+	}
+
+	return client, nil
 }
 
-// Returns list of top n stories on hackernews, where n is amount
-// Amount must be between 1 and 500 inclusive
-// Returns error if it fails
-func (c Client) GetTopStoryIds(amount int) ([]int, error) {
+// Sends a get request to the endpoint and decodes the json response into v.
+// Retries according to the client's retry policy.
+// Returns error if the request fails or the api does not respond with 200 OK
+func (c Client) getJSON(ctx context.Context, endpoint string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
 
-	if amount > 500 || amount <= 0 {
-		return nil, OutOfRangeErr
+	name := endpointName(req.URL.Path)
+	start := time.Now()
+	res, err := c.retry.Do(c.http, req, func(res *http.Response, err error) {
+		c.metrics.observeRetry(name)
+		if err == nil {
+			err = &StatusCodeErr{endpoint, res.StatusCode}
+		}
+		logger(c.logger).Warn("retrying request", "endpoint", name, "url", endpoint, "error", err)
+	})
+	if err != nil {
+		return err
 	}
+	defer res.Body.Close()
 
-	// api endpoint
-	endpoint := fmt.Sprintf("%s/%s", c.apiURL, TOP_STORIES_ENDPOINT)
+	logger(c.logger).Debug("request", "endpoint", name, "url", endpoint, "status", res.StatusCode, "duration", time.Since(start))
 
-	// send get request
-	res, err := c.http.Get(endpoint)
-	if err != nil {
-		return nil, err
+	if res.StatusCode != http.StatusOK {
+		return &StatusCodeErr{endpoint, res.StatusCode}
 	}
+
 	// read response as []byte
 	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
+		return err
+	}
+
+	// convert body into v. Uses the Json tags defined on struct.
+	return json.Unmarshal(body, v)
+}
+
+// Returns list of top n stories on hackernews, where n is amount
+// Amount must be between 1 and 500 inclusive
+// Returns error if it fails
+func (c Client) GetTopStoryIds(amount int) ([]int, error) {
+	return c.GetStoryIds("top", amount)
+}
+
+// Returns the first n story ids of a list on hackernews, where n is amount
+// list must be one of StoryLists and amount must be between 1 and the size of the list
+// Returns error if it fails
+func (c Client) GetStoryIds(list string, amount int) ([]int, error) {
+	return c.ListIDs(context.Background(), list, amount)
+}
+
+// Returns the first n story ids of a list, like GetStoryIds, stopping if ctx is cancelled
+func (c Client) ListIDs(ctx context.Context, list string, amount int) ([]int, error) {
+
+	if err := ValidateList(list, amount); err != nil {
 		return nil, err
 	}
 
+	// api endpoint
+	endpoint := fmt.Sprintf("%s/"+STORIES_ENDPOINT, c.apiURL, list)
+
 	var storyList []int
 
-	// convert from []byte into []int storing into storylist
-	if err := json.Unmarshal(body, &storyList); err != nil {
+	// convert from json into []int storing into storylist
+	if err := c.getJSON(ctx, endpoint, &storyList); err != nil {
 		return nil, err
 	}
 
@@ -77,29 +181,74 @@ func (c Client) GetTopStoryIds(amount int) ([]int, error) {
 
 }
 
+// Returns error if list is not one of StoryLists
+// or amount is not between 1 and the size of the list
+func ValidateList(list string, amount int) error {
+	maxAmount, ok := StoryLists[list]
+	if !ok {
+		return &InvalidListErr{list}
+	}
+
+	if amount > maxAmount || amount <= 0 {
+		return OutOfRangeErr
+	}
+	return nil
+}
+
 // Retrieves the item from hackerrank using the id passed in.
+// Returns *MissingItemErr if there is no item with the id
 func (c Client) GetItem(id int) (*RawItem, error) {
+	return c.Item(context.Background(), id)
+}
+
+// Retrieves the item with the id, like GetItem, stopping if ctx is cancelled
+func (c Client) Item(ctx context.Context, id int) (*RawItem, error) {
 	endpoint := fmt.Sprintf("%s/%s", c.apiURL, ITEM_ENDPOINT)
 	endpoint = fmt.Sprintf(endpoint, id)
-	res, err := c.http.Get(endpoint)
 
-	if err != nil {
+	// the api answers null for ids that do not exist
+	var item *RawItem
+	if err := c.getJSON(ctx, endpoint, &item); err != nil {
 		return nil, err
 	}
-
-	// read response into []byte
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
+	if item == nil {
+		return nil, &MissingItemErr{id}
 	}
 
-	item := &RawItem{}
+	return item, nil
+
+}
+
+// Retrieves the user with the id, which is their username.
+// Returns *MissingUserErr if there is no user with the id
+func (c Client) GetUser(id string) (*User, error) {
+	return c.User(context.Background(), id)
+}
 
-	// convert body into RawItem struct. Uses the Json tags defined on struct.
-	if err := json.Unmarshal(body, item); err != nil {
+// Retrieves the user with the id, like GetUser, stopping if ctx is cancelled
+func (c Client) User(ctx context.Context, id string) (*User, error) {
+	endpoint := fmt.Sprintf("%s/"+USER_ENDPOINT, c.apiURL, url.PathEscape(id))
+
+	// like items, the api answers null for users that do not exist
+	var user *User
+	if err := c.getJSON(ctx, endpoint, &user); err != nil {
 		return nil, err
 	}
+	if user == nil {
+		return nil, &MissingUserErr{id}
+	}
+	return user, nil
+}
 
-	return item, nil
+// Returns the id of the newest item on hackernews.
+// Every item id from 1 up to this value can be fetched with GetItem
+func (c Client) GetMaxItemId() (int, error) {
+	endpoint := fmt.Sprintf("%s/%s", c.apiURL, MAX_ITEM_ENDPOINT)
+
+	var maxID int
+	if err := c.getJSON(context.Background(), endpoint, &maxID); err != nil {
+		return 0, err
+	}
 
+	return maxID, nil
 }
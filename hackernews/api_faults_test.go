@@ -0,0 +1,120 @@
+package hackernews
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alis93/hn-scraper/hntest"
+)
+
+// Returns a client of a fake api that retries twice with a short backoff
+func helperRetryingAPI(t *testing.T) (*Client, *hntest.Server) {
+	t.Setenv(hntest.RECORD_ENV, "")
+	api := hntest.NewServer(t, "testdata")
+	client, err := NewClient(5, WithAPIURL(api.APIURL()), WithRetries(RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, api
+}
+
+func TestClientFaults(t *testing.T) {
+	log.Println("Testing client error paths")
+
+	tests := []struct {
+		name     string
+		fault    hntest.Fault
+		requests int
+		check    func(err error) bool
+	}{
+		{"recovers from errors", hntest.Fault{Status: http.StatusInternalServerError, Times: 2}, 3,
+			func(err error) bool { return err == nil }},
+		{"rate limited", hntest.Fault{Status: http.StatusTooManyRequests}, 3,
+			func(err error) bool { _, ok := err.(*StatusCodeErr); return ok }},
+		{"not retried", hntest.Fault{Status: http.StatusForbidden}, 1,
+			func(err error) bool { return ErrorKind(err) == "status_code" }},
+		{"connection reset", hntest.Fault{Reset: true}, 3,
+			func(err error) bool { return err != nil }},
+		{"reset once", hntest.Fault{Reset: true, Times: 1}, 2,
+			func(err error) bool { return err == nil }},
+		{"truncated body", hntest.Fault{Truncate: 20}, 1,
+			func(err error) bool { return err != nil }},
+		{"null item", hntest.Fault{Null: true}, 1,
+			func(err error) bool { _, ok := err.(*MissingItemErr); return ok && ErrorKind(err) == "missing_item" }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, api := helperRetryingAPI(t)
+			api.Inject(hntest.ROUTE_ITEM, test.fault)
+
+			item, err := client.GetItem(20324021)
+			if !test.check(err) {
+				t.Errorf("Unexpected result. Item %v error %v", item, err)
+			}
+			if err == nil && item.By != "moks" {
+				t.Errorf("Item incorrect after recovering. %+v", item)
+			}
+			if n := api.Requests("/v0/item/20324021.json"); n != test.requests {
+				t.Errorf("Expected %d requests but got %d", test.requests, n)
+			}
+		})
+	}
+}
+
+func TestClientSlowResponses(t *testing.T) {
+	log.Println("Testing slow responses")
+
+	tests := []struct {
+		name  string
+		fault hntest.Fault
+	}{
+		{"latency", hntest.Fault{Latency: time.Second}},
+		{"slow drip", hntest.Fault{Drip: 100 * time.Millisecond}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, api := helperRetryingAPI(t)
+			api.Inject(hntest.ROUTE_LIST, test.fault)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			if _, err := client.ListIDs(ctx, "top", 5); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected context.DeadlineExceeded but got %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("Expected the request to stop at the deadline but took %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestClientPartialFailures(t *testing.T) {
+	log.Println("Testing partial failures")
+
+	client, api := helperRetryingAPI(t)
+	// every other item request fails, but the same ones every run
+	api.Inject(hntest.ROUTE_ITEM, hntest.Fault{Status: http.StatusServiceUnavailable, Rate: 0.5})
+
+	ids, err := client.GetTopStoryIds(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetched, failed := 0, 0
+	for _, id := range ids {
+		if _, err := client.GetItem(id); err != nil {
+			failed++
+		} else {
+			fetched++
+		}
+	}
+	if fetched+failed != 5 || fetched == 0 {
+		t.Errorf("Expected retries to fetch some items but fetched %d and failed %d", fetched, failed)
+	}
+}
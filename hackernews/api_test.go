@@ -1,16 +1,26 @@
 package hackernews
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
 	"strconv"
 	"testing"
 
+	"github.com/alis93/hn-scraper/hntest"
 	"github.com/google/go-cmp/cmp"
 )
 
+// Returns a client of a fake api serving the fixtures in testdata
+func helperFakeAPI(t *testing.T) (*Client, *hntest.Server) {
+	api := hntest.NewServer(t, "testdata")
+	client, err := NewClient(5, WithAPIURL(api.APIURL()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, api
+}
+
 var timeoutTests = []struct {
 	input         int
 	expectedValid bool
@@ -57,9 +67,8 @@ func TestGetTopStoryIds(t *testing.T) {
 	testData := helperLoadBytes(t, "topstories.json")
 	parsedTestData := []int{}
 	json.Unmarshal(testData, &parsedTestData)
-	// setup mock client and server
-	client, server := mockServerHelper(testData)
-	defer server.Close()
+	// setup fake api and client
+	client, _ := helperFakeAPI(t)
 
 	numStories := -1
 
@@ -112,31 +121,90 @@ func TestGetItem(t *testing.T) {
 	testIds := []int{20324021, 20325395, 20325925, 20328871, 20329699}
 	file_template := "item_%d.json"
 
+	// one server serves every item, so each id must reach its own fixture
+	client, api := helperFakeAPI(t)
+
 	for _, testID := range testIds {
 		t.Run(strconv.Itoa(testID), func(t *testing.T) {
-			testID := testID //capture range variable
-			t.Parallel()
-
-			testItem := helperLoadBytes(t, fmt.Sprintf(file_template, testID))
-
-			client, server := mockServerHelper(testItem)
-			defer server.Close()
+			expected := &RawItem{}
+			if err := json.Unmarshal(helperLoadBytes(t, fmt.Sprintf(file_template, testID)), expected); err != nil {
+				t.Fatal(err)
+			}
 
 			item, err := client.GetItem(testID)
 			if err != nil {
-				t.Errorf("Error retrieving item from client. \n Reason: %s", err.Error())
+				t.Fatalf("Error retrieving item from client. \n Reason: %s", err.Error())
 			}
-
-			ts, err := json.Marshal(item)
-			if err != nil {
-				t.Fatalf("Failed to marshal item into json \n reason :%s", err.Error())
+			if !cmp.Equal(item, expected) {
+				t.Errorf("Expected output is not equal. \n%s", cmp.Diff(expected, item))
 			}
+		})
+	}
 
-			if bytes.Equal(ts, testItem) {
-				t.Fatalf("Expected output is not equal. Expected : %s, \n Actual : %s", testItem, ts)
-			}
+	for _, testID := range testIds {
+		if n := api.Requests(fmt.Sprintf("/v0/item/%d.json", testID)); n != 1 {
+			t.Errorf("Expected 1 request for item %d but got %d", testID, n)
+		}
+	}
+}
 
-		})
+func TestGetMaxItemId(t *testing.T) {
+	log.Println("Testing Get max item id")
+
+	client, _ := helperFakeAPI(t)
+
+	maxID, err := client.GetMaxItemId()
+	if err != nil {
+		t.Fatalf("Failed to load max item id. \n Reason : %s", err.Error())
+	}
+	if maxID != 20330835 {
+		t.Errorf("Max item id incorrect. \n\t Expected %d Actual %d", 20330835, maxID)
+	}
+}
+
+func TestGetUser(t *testing.T) {
+	log.Println("Testing Get user")
+
+	client, _ := helperFakeAPI(t)
+
+	user, err := client.GetUser("moks")
+	if err != nil {
+		t.Fatalf("Failed to load user. \n Reason : %s", err.Error())
+	}
+	expected := &User{ID: "moks", Created: 1389625564, Karma: 1023, Submitted: []int{20324021, 20100012, 19870455}}
+	if !cmp.Equal(user, expected) {
+		t.Errorf("User incorrect. \n%s", cmp.Diff(expected, user))
 	}
 
+	// the fake api answers null for users without a fixture, like the real one
+	_, err = client.GetUser("nobody")
+	if kind := ErrorKind(err); kind != "missing_user" {
+		t.Errorf("Expected missing_user error, got %v", err)
+	}
+}
+
+func TestGetStoryIds(t *testing.T) {
+	log.Println("Testing Get story ids of a list")
+
+	client, api := helperFakeAPI(t)
+	for list := range StoryLists {
+		api.SetList(list, []int{3, 2, 1})
+	}
+
+	if _, err := client.GetStoryIds("worst", 10); err == nil {
+		t.Errorf("Expected an error for an unknown list")
+	}
+	if _, err := client.GetStoryIds("ask", 201); err != OutOfRangeErr {
+		t.Errorf("Expected error was incorrect. \n\t Expected %v got %v", OutOfRangeErr, err)
+	}
+
+	for list := range StoryLists {
+		ids, err := client.GetStoryIds(list, 2)
+		if err != nil {
+			t.Fatalf("Failed to load %s stories. \n Reason : %s", list, err.Error())
+		}
+		if len(ids) != 2 {
+			t.Errorf("number of %s stories is incorrect. \n\t Expected %d, but got %d", list, 2, len(ids))
+		}
+	}
 }
@@ -0,0 +1,99 @@
+package hackernews
+
+import "sort"
+
+// Settings for converting items into stories.
+// Start from DefaultConverterConfig or a preset and change the fields needed.
+type ConverterConfig struct {
+	EmptyStringsAllowed    bool `json:"emptyStringsAllowed" yaml:"emptyStringsAllowed" toml:"emptyStringsAllowed"`
+	EnforceMaxStringLength bool `json:"enforceMaxStringLength" yaml:"enforceMaxStringLength" toml:"enforceMaxStringLength"`
+	// Max length of titles and authors in bytes. Longer strings are truncated
+	MaxStringLength int `json:"maxStringLength" yaml:"maxStringLength" toml:"maxStringLength"`
+	MinComments     int `json:"minComments" yaml:"minComments" toml:"minComments"`
+	MinPoints       int `json:"minPoints" yaml:"minPoints" toml:"minPoints"`
+	// Makes Convert run every check and return all failures as ValidationErrors,
+	// instead of stopping at the first failure.
+	CollectAllErrors bool          `json:"collectAllErrors" yaml:"collectAllErrors" toml:"collectAllErrors"`
+	Strings          StringOptions `json:"strings" yaml:"strings" toml:"strings"`
+	URLPolicy        URLPolicy     `json:"urlPolicy" yaml:"urlPolicy" toml:"urlPolicy"`
+}
+
+// Named converter configs
+var ConverterPresets = map[string]ConverterConfig{
+	"default": DefaultConverterConfig(),
+	// Only well discussed stories with tidy titles and public urls
+	"strict": {
+		EnforceMaxStringLength: true,
+		MaxStringLength:        160,
+		MinComments:            5,
+		MinPoints:              20,
+		Strings:                DefaultStringOptions,
+		URLPolicy: URLPolicy{
+			AllowedSchemes:   []string{"http", "https"},
+			RequireHost:      true,
+			RejectIPLiterals: true,
+			RejectPrivate:    true,
+		},
+	},
+	// Accepts every story with a valid url, including brand new ones without points or comments
+	"lenient": {
+		EmptyStringsAllowed: true,
+		MaxStringLength:     256,
+		Strings:             DefaultStringOptions,
+		URLPolicy:           DefaultURLPolicy,
+	},
+}
+
+// Returns the config used when nothing else is given.
+// Truncates strings to 256 bytes and requires at least 1 point and 1 comment
+func DefaultConverterConfig() ConverterConfig {
+	return ConverterConfig{
+		EnforceMaxStringLength: true,
+		MaxStringLength:        256,
+		MinComments:            1,
+		MinPoints:              1,
+		Strings:                DefaultStringOptions,
+		URLPolicy: URLPolicy{
+			AllowedSchemes: []string{"http", "https"},
+			RequireHost:    true,
+		},
+	}
+}
+
+// Returns the preset with the given name
+func ConverterPreset(name string) (ConverterConfig, error) {
+	cfg, ok := ConverterPresets[name]
+	if !ok {
+		return ConverterConfig{}, &UnknownPresetErr{name, PresetNames()}
+	}
+	// copy the schemes so changes to the returned config never change the preset
+	cfg.URLPolicy.AllowedSchemes = append([]string(nil), cfg.URLPolicy.AllowedSchemes...)
+	return cfg, nil
+}
+
+// Returns the names of every preset, sorted
+func PresetNames() []string {
+	names := make([]string, 0, len(ConverterPresets))
+	for name := range ConverterPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Returns error if the config cannot be used to convert items
+func (cfg ConverterConfig) Validate() error {
+	if cfg.EnforceMaxStringLength && cfg.MaxStringLength <= 0 {
+		return MaxStringErr
+	}
+	if cfg.MinComments < 0 {
+		return &MinValErr{0, cfg.MinComments}
+	}
+	if cfg.MinPoints < 0 {
+		return &MinValErr{0, cfg.MinPoints}
+	}
+	if len(cfg.URLPolicy.AllowedSchemes) == 0 {
+		return NoSchemesErr
+	}
+	return nil
+}
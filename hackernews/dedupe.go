@@ -0,0 +1,189 @@
+package hackernews
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// Estimated title similarity, between 0 and 1, at which two stories are duplicates
+	DUPLICATE_TITLE_SIMILARITY = 0.7
+	// Length in characters of the title shingles
+	SHINGLE_SIZE = 3
+	// Number of hash functions in a MinHash signature. More is more accurate but slower
+	MINHASH_SIZE = 128
+)
+
+// Groups stories that are the same article, submitted under a different url or title.
+// Stories are duplicates if they have the same canonical url,
+// or their titles have an estimated similarity of at least threshold.
+// The highest ranked story of each group is kept and the ids of the others are added to its DuplicateOf.
+// Returns copies of the kept stories in their original order, so the stories passed in are not changed
+// and deduping them again gives the same result.
+func Dedupe(stories []*Story, threshold float64) []*Story {
+	kept := make([]*Story, 0, len(stories))
+	for _, story := range stories {
+		if story != nil {
+			copied := *story
+			copied.DuplicateOf = append([]int(nil), story.DuplicateOf...)
+			kept = append(kept, &copied)
+		}
+	}
+
+	groups := newDisjointSet(len(kept))
+	byURL := make(map[string]int)
+	signatures := make([][]uint64, len(kept))
+	for i, story := range kept {
+		if story.CanonicalURL != "" {
+			if first, ok := byURL[story.CanonicalURL]; ok {
+				groups.union(first, i)
+			} else {
+				byURL[story.CanonicalURL] = i
+			}
+		}
+		signatures[i] = minHash(shingles(story.Title, SHINGLE_SIZE))
+	}
+
+	// titles are short and lists are at most a few hundred stories, so every pair is compared
+	for i := range kept {
+		for j := i + 1; j < len(kept); j++ {
+			if signatures[i] != nil && signatures[j] != nil && similarity(signatures[i], signatures[j]) >= threshold {
+				groups.union(i, j)
+			}
+		}
+	}
+
+	// pick the highest ranked story of each group
+	best := make(map[int]int)
+	for i, story := range kept {
+		root := groups.find(i)
+		if b, ok := best[root]; !ok || story.Rank < kept[b].Rank {
+			best[root] = i
+		}
+	}
+
+	deduped := make([]*Story, 0, len(best))
+	for i, story := range kept {
+		b := best[groups.find(i)]
+		if b != i {
+			kept[b].DuplicateOf = append(kept[b].DuplicateOf, story.ID)
+		}
+	}
+	for i, story := range kept {
+		if best[groups.find(i)] == i {
+			story.DuplicateOf = uniqueInts(story.DuplicateOf)
+			deduped = append(deduped, story)
+		}
+	}
+	return deduped
+}
+
+// Sorts ids and removes repeats, keeping nil as nil
+func uniqueInts(ids []int) []int {
+	sort.Ints(ids)
+	unique := ids[:0]
+	for _, id := range ids {
+		if len(unique) == 0 || id != unique[len(unique)-1] {
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) == 0 {
+		return nil
+	}
+	return unique
+}
+
+// Returns the set of overlapping size character shingles of the normalised title.
+// Titles shorter than size are a single shingle
+func shingles(title string, size int) map[string]bool {
+	normalised := []rune(normaliseTitle(title))
+	set := make(map[string]bool)
+	if len(normalised) == 0 {
+		return set
+	}
+	if len(normalised) <= size {
+		set[string(normalised)] = true
+		return set
+	}
+	for i := 0; i+size <= len(normalised); i++ {
+		set[string(normalised[i:i+size])] = true
+	}
+	return set
+}
+
+// Lowercases the title, drops punctuation and collapses whitespace
+func normaliseTitle(title string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// Returns the MinHash signature of the shingles, or nil if there are none.
+// Each of the MINHASH_SIZE hash functions is the fnv hash mixed with a different seed
+func minHash(shingles map[string]bool) []uint64 {
+	if len(shingles) == 0 {
+		return nil
+	}
+	signature := make([]uint64, MINHASH_SIZE)
+	for i := range signature {
+		signature[i] = ^uint64(0)
+	}
+	for shingle := range shingles {
+		h := fnv.New64a()
+		h.Write([]byte(shingle))
+		base := h.Sum64()
+		for i := range signature {
+			if v := mix(base ^ (uint64(i+1) * 0x9e3779b97f4a7c15)); v < signature[i] {
+				signature[i] = v
+			}
+		}
+	}
+	return signature
+}
+
+// splitmix64 finaliser, spreads the seeded hashes so they behave as independent hash functions
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// Estimates the Jaccard similarity of two sets from their MinHash signatures
+func similarity(a, b []uint64) float64 {
+	matches := 0
+	for i := range a {
+		if a[i] == b[i] {
+			matches++
+		}
+	}
+	return float64(matches) / float64(len(a))
+}
+
+// Union find over the indexes 0 to n-1
+type disjointSet []int
+
+func newDisjointSet(n int) disjointSet {
+	set := make(disjointSet, n)
+	for i := range set {
+		set[i] = i
+	}
+	return set
+}
+
+func (s disjointSet) find(i int) int {
+	for s[i] != i {
+		s[i] = s[s[i]]
+		i = s[i]
+	}
+	return i
+}
+
+func (s disjointSet) union(i, j int) {
+	s[s.find(i)] = s.find(j)
+}
@@ -0,0 +1,97 @@
+package hackernews
+
+import (
+	"log"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDedupe(t *testing.T) {
+	log.Println("Testing duplicate stories are grouped")
+
+	stories := []*Story{
+		{ID: 1, Rank: 1, Title: "Go 1.13 is released", CanonicalURL: "https://blog.golang.org/go1.13"},
+		{ID: 2, Rank: 2, Title: "Show HN: A tiny database written in Rust", CanonicalURL: "https://example.com/tinydb"},
+		{ID: 3, Rank: 3, Title: "The Go 1.13 release", CanonicalURL: "https://blog.golang.org/go1.13"},
+		{ID: 4, Rank: 4, Title: "Why we moved off the cloud", CanonicalURL: "https://example.org/cloud"},
+		{ID: 5, Rank: 5, Title: "Show HN: A tiny database written in Rust!", CanonicalURL: "https://github.com/someone/tinydb"},
+		nil,
+		{ID: 6, Rank: 6, Title: "Why we moved off the cloud", CanonicalURL: "https://medium.com/cloud-story"},
+		{ID: 7, Rank: 7, Title: "Something else entirely", CanonicalURL: "https://example.net/"},
+	}
+
+	expected := []*Story{
+		{ID: 1, Rank: 1, Title: "Go 1.13 is released", CanonicalURL: "https://blog.golang.org/go1.13", DuplicateOf: []int{3}},
+		{ID: 2, Rank: 2, Title: "Show HN: A tiny database written in Rust", CanonicalURL: "https://example.com/tinydb", DuplicateOf: []int{5}},
+		{ID: 4, Rank: 4, Title: "Why we moved off the cloud", CanonicalURL: "https://example.org/cloud", DuplicateOf: []int{6}},
+		{ID: 7, Rank: 7, Title: "Something else entirely", CanonicalURL: "https://example.net/"},
+	}
+
+	if deduped := Dedupe(stories, DUPLICATE_TITLE_SIMILARITY); !cmp.Equal(deduped, expected) {
+		t.Errorf("Deduped stories incorrect. \n\t %s", cmp.Diff(expected, deduped))
+	}
+}
+
+func TestDedupeKeepsHighestRank(t *testing.T) {
+	stories := []*Story{
+		{ID: 10, Rank: 3, Title: "Same title"},
+		{ID: 11, Rank: 1, Title: "same title."},
+		{ID: 12, Rank: 2, Title: "Same Title"},
+	}
+
+	deduped := Dedupe(stories, DUPLICATE_TITLE_SIMILARITY)
+	if len(deduped) != 1 || deduped[0].ID != 11 {
+		t.Fatalf("Expected only story 11 to be kept but got %+v", deduped)
+	}
+	if !cmp.Equal(deduped[0].DuplicateOf, []int{10, 12}) {
+		t.Errorf("DuplicateOf incorrect. \n\t Expected %v Actual %v", []int{10, 12}, deduped[0].DuplicateOf)
+	}
+}
+
+func TestDedupeTwice(t *testing.T) {
+	log.Println("Testing deduping the same stories twice")
+
+	stories := []*Story{
+		{ID: 1, Rank: 1, Title: "Same title"},
+		{ID: 2, Rank: 2, Title: "Same title"},
+		{ID: 3, Rank: 3, Title: "Different title entirely"},
+	}
+
+	first := Dedupe(stories, DUPLICATE_TITLE_SIMILARITY)
+	second := Dedupe(stories, DUPLICATE_TITLE_SIMILARITY)
+	if !cmp.Equal(first, second) {
+		t.Errorf("Deduping again gave a different result. \n\t %s", cmp.Diff(first, second))
+	}
+	if !cmp.Equal(second[0].DuplicateOf, []int{2}) {
+		t.Errorf("DuplicateOf incorrect. \n\t Expected %v Actual %v", []int{2}, second[0].DuplicateOf)
+	}
+	if stories[0].DuplicateOf != nil {
+		t.Errorf("Dedupe changed the stories passed in, DuplicateOf is %v", stories[0].DuplicateOf)
+	}
+
+	// deduping the result again finds no more duplicates
+	if again := Dedupe(first, DUPLICATE_TITLE_SIMILARITY); !cmp.Equal(again, first) {
+		t.Errorf("Deduping the deduped stories changed them. \n\t %s", cmp.Diff(first, again))
+	}
+}
+
+func TestTitleSimilarity(t *testing.T) {
+	similarityTests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"Show HN: My new project", "Show HN: My new project!", true},
+		{"Google’s robots.txt parser is now open source", "Google's robots.txt parser is now open-source", true},
+		{"Rust 1.36 released", "Python 3.8 released", false},
+		{"", "", false},
+	}
+
+	for _, test := range similarityTests {
+		a, b := minHash(shingles(test.a, SHINGLE_SIZE)), minHash(shingles(test.b, SHINGLE_SIZE))
+		similar := a != nil && b != nil && similarity(a, b) >= DUPLICATE_TITLE_SIMILARITY
+		if similar != test.expected {
+			t.Errorf("%q and %q similar should be %t", test.a, test.b, test.expected)
+		}
+	}
+}
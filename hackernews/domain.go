@@ -0,0 +1,93 @@
+package hackernews
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Totals for every story from one domain
+type DomainStats struct {
+	Domain         string  `json:"domain"`
+	Stories        int     `json:"stories"`
+	TotalPoints    int     `json:"totalPoints"`
+	MedianComments float64 `json:"medianComments"`
+}
+
+// Returns the registrable domain of rawURL, for example bbc.co.uk for https://www.bbc.co.uk/news.
+// Uses the public suffix list compiled into golang.org/x/net, so no network access is needed.
+// IP addresses and hosts that are themselves public suffixes are returned as they are.
+func ExtractDomain(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" || net.ParseIP(host) != nil {
+		return host
+	}
+
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
+
+// Groups stories by domain.
+// Sorted by most stories first, then most points, then domain name
+func AggregateByDomain(stories []*Story) []DomainStats {
+	comments := make(map[string][]int)
+	totals := make(map[string]*DomainStats)
+
+	for _, story := range stories {
+		if story == nil {
+			continue
+		}
+		stats, ok := totals[story.Domain]
+		if !ok {
+			stats = &DomainStats{Domain: story.Domain}
+			totals[story.Domain] = stats
+		}
+		stats.Stories++
+		stats.TotalPoints += story.Points
+		comments[story.Domain] = append(comments[story.Domain], story.Comments)
+	}
+
+	aggregated := make([]DomainStats, 0, len(totals))
+	for domain, stats := range totals {
+		stats.MedianComments = median(comments[domain])
+		aggregated = append(aggregated, *stats)
+	}
+
+	sort.Slice(aggregated, func(i, j int) bool {
+		a, b := aggregated[i], aggregated[j]
+		if a.Stories != b.Stories {
+			return a.Stories > b.Stories
+		}
+		if a.TotalPoints != b.TotalPoints {
+			return a.TotalPoints > b.TotalPoints
+		}
+		return a.Domain < b.Domain
+	})
+	return aggregated
+}
+
+// Returns the median of values, averaging the middle two if there is an even number
+func median(values []int) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]int, len(values))
+	copy(sorted, values)
+	sort.Ints(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return float64(sorted[mid-1]+sorted[mid]) / 2
+	}
+	return float64(sorted[mid])
+}
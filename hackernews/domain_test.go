@@ -0,0 +1,73 @@
+package hackernews
+
+import (
+	"log"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExtractDomain(t *testing.T) {
+	log.Println("Testing domain extraction")
+
+	domainTests := []struct {
+		input    string
+		expected string
+	}{
+		{"http://natpryce.com/articles/000819.html", "natpryce.com"},
+		{"https://opensource.googleblog.com/2019/07/post.html", "googleblog.com"},
+		{"https://www.bbc.co.uk/news", "bbc.co.uk"},
+		{"https://WWW.BBC.CO.UK./news", "bbc.co.uk"},
+		{"https://someone.github.io/project", "someone.github.io"},
+		{"http://93.184.216.34/page", "93.184.216.34"},
+		{"https://co.uk/", "co.uk"},
+		{"not a url", ""},
+	}
+
+	for _, test := range domainTests {
+		t.Run(test.input, func(t *testing.T) {
+			if domain := ExtractDomain(test.input); domain != test.expected {
+				t.Errorf("Domain incorrect. \n\t Expected %s Actual %s", test.expected, domain)
+			}
+		})
+	}
+}
+
+func TestAggregateByDomain(t *testing.T) {
+	log.Println("Testing aggregating stories by domain")
+
+	stories := []*Story{
+		{Domain: "github.com", Points: 100, Comments: 10},
+		{Domain: "nytimes.com", Points: 500, Comments: 300},
+		{Domain: "github.com", Points: 50, Comments: 40},
+		{Domain: "github.com", Points: 20, Comments: 1},
+		{Domain: "bbc.co.uk", Points: 500, Comments: 5},
+		{Domain: "bbc.co.uk", Points: 10, Comments: 6},
+		nil,
+	}
+
+	expected := []DomainStats{
+		{Domain: "github.com", Stories: 3, TotalPoints: 170, MedianComments: 10},
+		{Domain: "bbc.co.uk", Stories: 2, TotalPoints: 510, MedianComments: 5.5},
+		{Domain: "nytimes.com", Stories: 1, TotalPoints: 500, MedianComments: 300},
+	}
+
+	if aggregated := AggregateByDomain(stories); !cmp.Equal(aggregated, expected) {
+		t.Errorf("Aggregated domains incorrect. \n\t Expected %+v \n\t Actual %+v", expected, aggregated)
+	}
+
+	if aggregated := AggregateByDomain(nil); len(aggregated) != 0 {
+		t.Errorf("Expected no domains but got %+v", aggregated)
+	}
+}
+
+func TestConvertSetsDomain(t *testing.T) {
+	cnv, _ := NewItemConverter(DefaultConverterConfig())
+	story, err := cnv.Convert(2, loadItem(t, 20325395))
+	if err != nil {
+		t.Fatalf("Failed to convert story: Reason %s", err.Error())
+	}
+	if story.Domain != "googleblog.com" {
+		t.Errorf("Domain incorrect. \n\t Expected %s Actual %s", "googleblog.com", story.Domain)
+	}
+}
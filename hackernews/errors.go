@@ -1,6 +1,9 @@
 package hackernews
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 var (
 	ConvertErr        = fmt.Errorf("Failed to convert item to story.")
@@ -8,8 +11,22 @@ var (
 	InvalidTimeOutErr = fmt.Errorf("timeout must be a positive number greater than 0")
 	EmptyStringErr    = fmt.Errorf("Empty string not allowed!")
 	MaxStringErr      = fmt.Errorf("Max string length must be more than 0")
+	NoSchemesErr      = fmt.Errorf("URL policy must allow at least one scheme")
+	NilItemErr        = fmt.Errorf("Item is nil. It may have failed to be retrieved")
 )
 
+// A single failed check on a field of an item
+type FieldError struct {
+	Field string
+	Rule  string
+	Value interface{}
+	Err   error
+}
+
+// Every failed check of an item, returned when a converter collects all errors.
+// Use errors.As to get at the individual field errors.
+type ValidationErrors []*FieldError
+
 type ClientErr struct {
 	msg string
 }
@@ -25,7 +42,54 @@ type InvalidItemTypeErr struct {
 }
 
 type InvalidURLErr struct {
-	value string
+	value  string
+	reason string
+}
+
+type InvalidListErr struct {
+	list string
+}
+
+type UnknownPresetErr struct {
+	name  string
+	valid []string
+}
+
+type StatusCodeErr struct {
+	endpoint string
+	code     int
+}
+
+type MissingItemErr struct {
+	id int
+}
+
+// Returns the error every Source gives when there is no item with the id
+func NewMissingItemErr(id int) *MissingItemErr {
+	return &MissingItemErr{id}
+}
+
+// Returns the error for a request to url answered with the status code,
+// so sources reading from other places than the api are classified the same by ErrorKind
+func NewStatusCodeErr(url string, code int) *StatusCodeErr {
+	return &StatusCodeErr{url, code}
+}
+
+type MissingUserErr struct {
+	id string
+}
+
+type LineErr struct {
+	line int
+	err  error
+}
+
+func (e *MissingItemErr) Error() string {
+	return fmt.Sprintf("Item %d does not exist", e.id)
+}
+
+func (e *MissingUserErr) Error() string {
+	return fmt.Sprintf("User %s does not exist", e.id)
 }
 
 func (e *ClientErr) Error() string {
@@ -41,5 +105,88 @@ func (e *InvalidItemTypeErr) Error() string {
 }
 
 func (e *InvalidURLErr) Error() string {
-	return fmt.Sprintf("item has an invalid url scheme. \t %s", e.value)
+	return fmt.Sprintf("item has an invalid url, %s. \t %s", e.reason, e.value)
+}
+
+func (e *StatusCodeErr) Error() string {
+	return fmt.Sprintf("Request to %s failed with status code %d", e.endpoint, e.code)
+}
+
+func (e *InvalidListErr) Error() string {
+	return fmt.Sprintf("%s is not a story list. Must be one of top, new, best, ask, show or job", e.list)
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s failed %s with value %q: %s", e.Field, e.Rule, fmt.Sprint(e.Value), e.Err.Error())
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fieldErr := range e {
+		msgs[i] = fieldErr.Error()
+	}
+	return fmt.Sprintf("%d validation errors. %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Allows errors.Is and errors.As to match any of the field errors
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, fieldErr := range e {
+		errs[i] = fieldErr
+	}
+	return errs
+}
+
+func (e *UnknownPresetErr) Error() string {
+	return fmt.Sprintf("%s is not a converter preset. Must be one of %s", e.name, strings.Join(e.valid, ", "))
+}
+
+func (e *LineErr) Error() string {
+	return fmt.Sprintf("Line %d is not a valid story. \t %s", e.line, e.err.Error())
+}
+
+func (e *LineErr) Unwrap() error {
+	return e.err
+}
+
+// Returns a short name for the kind of error, for use in metrics and logs
+func ErrorKind(err error) string {
+	switch e := err.(type) {
+	case *FieldError:
+		return ErrorKind(e.Err)
+	case ValidationErrors:
+		return "validation"
+	case *MinValErr:
+		return "min_value"
+	case *InvalidItemTypeErr:
+		return "invalid_type"
+	case *InvalidURLErr:
+		return "invalid_url"
+	case *StatusCodeErr:
+		return "status_code"
+	case *InvalidListErr:
+		return "invalid_list"
+	case *MissingItemErr:
+		return "missing_item"
+	case *MissingUserErr:
+		return "missing_user"
+	}
+
+	switch err {
+	case EmptyStringErr:
+		return "empty_string"
+	case MaxStringErr:
+		return "max_string"
+	case OutOfRangeErr:
+		return "out_of_range"
+	case NilItemErr:
+		return "nil_item"
+	case nil:
+		return ""
+	}
+	return "other"
 }
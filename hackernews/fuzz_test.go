@@ -0,0 +1,205 @@
+package hackernews
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"testing"
+	"unicode/utf8"
+)
+
+// Fuzz targets for decoding and converting items. The seeds run with go test,
+// use go test -fuzz=FuzzConvert ./hackernews to search for new failures.
+
+var fuzzItemIds = []int{20324021, 20325395, 20325925, 20328871, 20329699}
+
+// Converters whose invariants are checked by the fuzz targets
+func fuzzConverters(t *testing.T) map[string]*ItemConverter {
+	converters := map[string]*ItemConverter{}
+	for _, name := range PresetNames() {
+		cfg, err := ConverterPreset(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cnv, err := NewItemConverter(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		converters[name] = cnv
+	}
+	// a limit smaller than the ellipsis and most multi byte characters
+	tiny := DefaultConverterConfig()
+	tiny.MaxStringLength = 2
+	cnv, err := NewItemConverter(tiny)
+	if err != nil {
+		t.Fatal(err)
+	}
+	converters["tiny"] = cnv
+	return converters
+}
+
+// Fails the test if a converted string breaks the rules of cnv
+func checkConvertedStr(t *testing.T, cnv *ItemConverter, field, input, output string) {
+	t.Helper()
+	if !utf8.ValidString(output) {
+		t.Errorf("%s %q converted to invalid utf8 %q", field, input, output)
+	}
+	if cnv.enforceMaxStringLength && len(output) > cnv.maxStringLength {
+		t.Errorf("%s %q converted to %d bytes, max is %d", field, input, len(output), cnv.maxStringLength)
+	}
+	if !cnv.emptyStringsAllowed && output == "" {
+		t.Errorf("%s %q converted to an empty string", field, input)
+	}
+}
+
+func FuzzRawItemJSON(f *testing.F) {
+	for _, id := range fuzzItemIds {
+		f.Add(helperLoadBytes(f, fmt.Sprintf("item_%d.json", id)))
+	}
+	f.Add([]byte(`null`))
+	f.Add([]byte(`{"id":1,"type":"story","title":"é\ud800","kids":[1,2,null]}`))
+	f.Add([]byte(`{"id":1e3,"score":"10"}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		converters := fuzzConverters(t)
+		var item *RawItem
+		if err := json.Unmarshal(data, &item); err != nil {
+			return
+		}
+
+		// decoded items survive a round trip
+		if item != nil {
+			encoded, err := json.Marshal(item)
+			if err != nil {
+				t.Fatalf("unable to encode decoded item %+v: %v", item, err)
+			}
+			decoded := &RawItem{}
+			if err := json.Unmarshal(encoded, decoded); err != nil {
+				t.Fatalf("unable to decode %s: %v", encoded, err)
+			}
+			if decoded.ID != item.ID || decoded.Title != item.Title || decoded.Score != item.Score {
+				t.Errorf("round trip changed item from %+v to %+v", item, decoded)
+			}
+		}
+
+		for name, cnv := range converters {
+			story, err := cnv.Convert(1, item)
+			if item == nil && !errors.Is(err, NilItemErr) {
+				t.Errorf("%s: expected NilItemErr for null item, got %v", name, err)
+			}
+			if err != nil {
+				continue
+			}
+			checkConvertedStr(t, cnv, "title", item.Title, story.Title)
+			checkConvertedStr(t, cnv, "author", item.By, story.Author)
+		}
+	})
+}
+
+func FuzzValidateStr(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		" ",
+		"Mistakes we made adopting event sourcing and how we recovered",
+		"Google&#x2019;s robots.txt parser &amp; more",
+		"e\u0301t\u00e9 \U0001F468\u200d\U0001F469\u200d\U0001F467",
+		"\xff\xfe invalid \xc3",
+		"tab\tnew\nline\x00null\u200b",
+		"日本語のタイトル",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, str string) {
+		for name, cnv := range fuzzConverters(t) {
+			output, err := cnv.ValidateStr(str)
+			if err != nil {
+				if output != "" {
+					t.Errorf("%s: expected empty string with error %v, got %q", name, err, output)
+				}
+				continue
+			}
+			checkConvertedStr(t, cnv, name, str, output)
+		}
+	})
+}
+
+func FuzzTruncate(f *testing.F) {
+	f.Add("Mistakes we made adopting event sourcing", 10, "…")
+	f.Add("ééé", 3, "")
+	f.Add("\U0001F468\u200d\U0001F469\u200d\U0001F467 family", 5, "...")
+	f.Add("日本語", 1, "…")
+
+	f.Fuzz(func(t *testing.T, str string, maxBytes int, ellipsis string) {
+		if !utf8.ValidString(str) || !utf8.ValidString(ellipsis) || maxBytes < 0 {
+			// the converter cleans strings before truncating them
+			return
+		}
+		truncated := truncate(str, maxBytes, ellipsis)
+		if !utf8.ValidString(truncated) {
+			t.Errorf("truncate(%q, %d, %q) is invalid utf8 %q", str, maxBytes, ellipsis, truncated)
+		}
+		if len(str) <= maxBytes && truncated != str {
+			t.Errorf("truncate(%q, %d, %q) changed a short string to %q", str, maxBytes, ellipsis, truncated)
+		}
+		if len(str) > maxBytes && len(truncated) > maxBytes {
+			t.Errorf("truncate(%q, %d, %q) is %d bytes", str, maxBytes, ellipsis, len(truncated))
+		}
+	})
+}
+
+func FuzzConvert(f *testing.F) {
+	for _, id := range fuzzItemIds {
+		item := &RawItem{}
+		if err := json.Unmarshal(helperLoadBytes(f, fmt.Sprintf("item_%d.json", id)), item); err != nil {
+			f.Fatal(err)
+		}
+		f.Add(1, item.ID, item.ItemType, item.Title, item.By, item.URL, item.Score, item.Descendants)
+	}
+	f.Add(0, -1, "", "", "", "", -1, -1)
+	f.Add(3, 7, "story", "\xff\xfe", "á", "http://[::1]:80/", 1, 1)
+	f.Add(2, 8, "story", "x", "y", "HTTPS://EXAMPLE.com/%zz?utm_source=hn", 100, 100)
+
+	f.Fuzz(func(t *testing.T, rank, id int, itemType, title, by, url string, score, descendants int) {
+		item := &RawItem{ID: id, ItemType: itemType, Title: title, By: by, URL: url, Score: score, Descendants: descendants}
+		for name, cnv := range fuzzConverters(t) {
+			story, err := cnv.Convert(rank, item)
+			if err != nil {
+				if story != nil {
+					t.Errorf("%s: expected no story with error %v, got %+v", name, err, story)
+				}
+				continue
+			}
+			if story == nil {
+				t.Fatalf("%s: expected a story or an error for %+v", name, item)
+			}
+			checkConvertedStr(t, cnv, "title", title, story.Title)
+			checkConvertedStr(t, cnv, "author", by, story.Author)
+			if !utf8.ValidString(story.URL) || !utf8.ValidString(story.Domain) {
+				t.Errorf("%s: url %q converted to invalid utf8 %q %q", name, url, story.URL, story.Domain)
+			}
+			if story.Points < cnv.minPoints || story.Comments < cnv.minComments {
+				t.Errorf("%s: story %+v is below the minimums", name, story)
+			}
+		}
+	})
+}
+
+func TestConvertNilItem(t *testing.T) {
+
+	log.Println("Testing item converter with nil item")
+
+	for name, cnv := range fuzzConverters(t) {
+		story, err := cnv.Convert(1, nil)
+		if !errors.Is(err, NilItemErr) {
+			t.Errorf("%s: expected NilItemErr, got %v", name, err)
+		}
+		if story != nil {
+			t.Errorf("%s: expected no story, got %+v", name, story)
+		}
+		if kind := ErrorKind(err); kind != "nil_item" {
+			t.Errorf("%s: expected error kind nil_item, got %s", name, kind)
+		}
+	}
+}
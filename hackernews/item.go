@@ -28,15 +28,31 @@ type RawItem struct {
 	Descendants int    `json:"descendants"`
 }
 
+// Represents a hackernews user retrieved from the api.
+type User struct {
+	// The username
+	ID      string `json:"id"`
+	Created int    `json:"created"`
+	Karma   int    `json:"karma"`
+	// Html the user wrote about themselves
+	About string `json:"about"`
+	// Ids of the user's stories, comments and polls, newest first
+	Submitted []int `json:"submitted"`
+}
+
 // Represents a Story item
 type Story struct {
-	ID       int    `json:"id"`
-	Title    string `json:"title"`
-	URL      string `json:"uri"`
-	Author   string `json:"author"`
-	Points   int    `json:"points"`
-	Comments int    `json:"comments"`
-	Rank     int    `json:"rank"`
+	ID           int    `json:"id"`
+	Title        string `json:"title"`
+	URL          string `json:"uri"`
+	CanonicalURL string `json:"canonicalUri"`
+	Domain       string `json:"domain"`
+	Author       string `json:"author"`
+	Points       int    `json:"points"`
+	Comments     int    `json:"comments"`
+	Rank         int    `json:"rank"`
+	// Ids of other submissions of the same article, set by Dedupe
+	DuplicateOf []int `json:"duplicateOf,omitempty"`
 }
 
 // Returns the id of the item
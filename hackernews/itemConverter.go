@@ -1,6 +1,8 @@
 package hackernews
 
-import "fmt"
+import (
+	"log/slog"
+)
 
 // type Converter interface {
 // 	Convert() Item
@@ -13,49 +15,80 @@ type ItemConverter struct {
 	maxStringLength        int
 	minComments            int
 	minPoints              int
+	strOpts                StringOptions
+	urlPolicy              URLPolicy
+	collectAllErrors       bool
+	metrics                *ConverterMetrics
+	logger                 *slog.Logger
 }
 
-func NewItemConverter(emptyStringAllowed, enforceMaxStrLength bool, maxStrLength, minComments, minPoints int) (*ItemConverter, error) {
-	if enforceMaxStrLength && maxStrLength <= 0 {
-		return nil, fmt.Errorf("If enforceMaxStringLength is set, then maxStringLength must be more than 0")
+// Optional settings applied when creating a converter
+type ConverterOption func(*ItemConverter)
+
+// Records conversions and failures by error kind in m.
+func WithConversionMetrics(m *ConverterMetrics) ConverterOption {
+	return func(cnv *ItemConverter) {
+		cnv.metrics = m
+	}
+}
+
+// Logs items that fail to convert at debug level to logger.
+// By default nothing is logged.
+func WithConversionLogger(logger *slog.Logger) ConverterOption {
+	return func(cnv *ItemConverter) {
+		cnv.logger = logger
+	}
+}
+
+// Creates a converter with the given config.
+// Returns error if the config is invalid
+func NewItemConverter(cfg ConverterConfig, opts ...ConverterOption) (*ItemConverter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
-	if minComments <= 0 {
-		return nil, &MinValErr{1, minComments}
+	cnv := &ItemConverter{
+		emptyStringsAllowed:    cfg.EmptyStringsAllowed,
+		enforceMaxStringLength: cfg.EnforceMaxStringLength,
+		maxStringLength:        cfg.MaxStringLength,
+		minComments:            cfg.MinComments,
+		minPoints:              cfg.MinPoints,
+		strOpts:                cfg.Strings,
+		urlPolicy:              cfg.URLPolicy,
+		collectAllErrors:       cfg.CollectAllErrors,
 	}
-	if minPoints <= 0 {
-		return nil, &MinValErr{1, minPoints}
+	for _, opt := range opts {
+		opt(cnv)
 	}
-	return &ItemConverter{
-		emptyStringsAllowed:    emptyStringAllowed,
-		enforceMaxStringLength: enforceMaxStrLength,
-		maxStringLength:        maxStrLength,
-		minComments:            minComments,
-		minPoints:              minPoints,
-	}, nil
+	return cnv, nil
 }
 
 // Helper function to validate strings.
-// Tests and validates string.
+// Cleans the string according to the converter's StringOptions, then tests and validates it.
 // Returns error if invalid.
-// Returns string if valid.
-// truncates to required length if enforceMaxStringLength and maxStringLength are set
+// Returns string if valid. The string is always valid UTF-8.
+// truncates to required length if enforceMaxStringLength and maxStringLength are set,
+// without splitting multi-byte characters
 func (cnv ItemConverter) ValidateStr(str string) (string, error) {
 
-	strLen := len(str)
+	finalStr := cnv.strOpts.clean(str)
+
+	strLen := len(finalStr)
 	// test empty strings
 	if !cnv.emptyStringsAllowed && strLen <= 0 {
 		return "", EmptyStringErr
 	}
 
-	finalStr := str
-
 	// test string length
 	// if string length more than max, truncate string
 	if cnv.enforceMaxStringLength && strLen > cnv.maxStringLength {
 		if cnv.maxStringLength <= 0 {
 			return "", MaxStringErr
 		}
-		finalStr = str[:cnv.maxStringLength]
+		finalStr = truncate(finalStr, cnv.maxStringLength, cnv.strOpts.Ellipsis)
+		// a limit smaller than the first character leaves nothing
+		if !cnv.emptyStringsAllowed && finalStr == "" {
+			return "", EmptyStringErr
+		}
 	}
 
 	return finalStr, nil
@@ -84,50 +117,109 @@ func (cnv ItemConverter) countComments(i *RawItem) (int, error) {
 }
 
 // Converts a RawItem into a Story struct
-// Only works if the ItemType is story.
+// Only works if the ItemType is story. Returns NilItemErr if item is nil.
 // Validates and sets each field and returns a new story item
 // NOTE: used value receiver as we are not mutating
 func (cnv ItemConverter) Convert(rank int, item *RawItem) (*Story, error) {
+	story, err := cnv.convert(rank, item)
+	cnv.metrics.observeConversion(err)
+	if err != nil {
+		id := 0
+		if item != nil {
+			id = item.GetID()
+		}
+		logger(cnv.logger).Debug("item rejected", "story_id", id, "rank", rank, "error", err, "error_kind", ErrorKind(err))
+	}
+	return story, err
+}
+
+func (cnv ItemConverter) convert(rank int, item *RawItem) (*Story, error) {
+	// items that failed to be retrieved are nil
+	if item == nil {
+		return nil, NilItemErr
+	}
+	v := &validation{collectAll: cnv.collectAllErrors}
+
 	expectedType := "story"
-	if item.ItemType != expectedType {
-		return nil, &InvalidItemTypeErr{expectedType, item.ItemType}
+	if item.ItemType != expectedType && v.fail("type", item.ItemType, &InvalidItemTypeErr{expectedType, item.ItemType}) {
+		return nil, v.err()
 	}
 
-	if rank <= 0 {
-		return nil, &MinValErr{min: 1, actual: rank}
+	if rank <= 0 && v.fail("rank", rank, &MinValErr{min: 1, actual: rank}) {
+		return nil, v.err()
 	}
 
 	validTitle, err := cnv.ValidateStr(item.Title)
-	if err != nil {
-		return nil, err
+	if v.fail("title", item.Title, err) {
+		return nil, v.err()
 	}
 
 	validAuthor, err := cnv.ValidateStr(item.By)
-	if err != nil {
-		return nil, err
+	if v.fail("author", item.By, err) {
+		return nil, v.err()
 	}
 
-	if !isValidURLScheme(item.URL) {
-		return nil, &InvalidURLErr{item.URL}
+	// a converter that was not created from a config uses the default policy
+	policy := cnv.urlPolicy
+	if len(policy.AllowedSchemes) == 0 {
+		policy = DefaultURLPolicy
 	}
+	if v.fail("url", item.URL, policy.Validate(item.URL)) {
+		return nil, v.err()
+	}
+	canonicalURL, _ := CanonicalizeURL(item.URL)
 
 	points, err := cnv.calculatePoints(item)
-	if err != nil {
-		return nil, err
+	if v.fail("points", item.Score, err) {
+		return nil, v.err()
 	}
 	comments, err := cnv.countComments(item)
-	if err != nil {
+	if v.fail("comments", item.Descendants, err) {
+		return nil, v.err()
+	}
+
+	if err := v.err(); err != nil {
 		return nil, err
 	}
 
 	story := &Story{
-		ID:       item.GetID(),
-		Title:    validTitle,
-		URL:      item.URL,
-		Author:   validAuthor,
-		Points:   points,
-		Comments: comments,
-		Rank:     rank,
+		ID:           item.GetID(),
+		Title:        validTitle,
+		URL:          item.URL,
+		CanonicalURL: canonicalURL,
+		Domain:       ExtractDomain(item.URL),
+		Author:       validAuthor,
+		Points:       points,
+		Comments:     comments,
+		Rank:         rank,
 	}
 	return story, nil
 }
+
+// Keeps track of failed checks while converting an item
+type validation struct {
+	collectAll bool
+	errs       ValidationErrors
+}
+
+// Records err against field if it is not nil.
+// Returns true if conversion should stop, which is on the first error unless collecting all errors.
+func (v *validation) fail(field string, value interface{}, err error) bool {
+	if err == nil {
+		return false
+	}
+	v.errs = append(v.errs, &FieldError{field, ErrorKind(err), value, err})
+	return !v.collectAll
+}
+
+// Returns every failed check as ValidationErrors when collecting all errors,
+// otherwise just the first error. Returns nil if every check passed
+func (v *validation) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	if v.collectAll {
+		return v.errs
+	}
+	return v.errs[0].Err
+}
@@ -1,9 +1,12 @@
 package hackernews
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"strconv"
 	"testing"
 
@@ -33,7 +36,7 @@ func TestValidateStrEmptyStringAllowed(t *testing.T) {
 
 	log.Println("Testing item converter validate empty string option")
 
-	cnv := &ItemConverter{false, false, 0, 0, 0}
+	cnv := &ItemConverter{emptyStringsAllowed: false, enforceMaxStringLength: false, maxStringLength: 0, minComments: 0, minPoints: 0}
 
 	commentTests := []struct {
 		input       string
@@ -61,12 +64,12 @@ func TestValidateStrEmptyStringAllowed(t *testing.T) {
 func TestValidateStrMaxStringLength(t *testing.T) {
 	log.Println("Testing item converter validate string maxlength option")
 
-	cnv := &ItemConverter{false, true, 0, 0, 0}
+	cnv := &ItemConverter{emptyStringsAllowed: false, enforceMaxStringLength: true, maxStringLength: 0, minComments: 0, minPoints: 0}
 	if _, err := cnv.ValidateStr("random"); err != MaxStringErr {
 		t.Errorf("Expected error was incorrect. \n\t Expected %s got %s", MaxStringErr, err.Error())
 	}
 
-	cnv = &ItemConverter{true, true, 20, 0, 0}
+	cnv = &ItemConverter{emptyStringsAllowed: true, enforceMaxStringLength: true, maxStringLength: 20, minComments: 0, minPoints: 0}
 
 	commentTests := []struct {
 		input       string
@@ -94,7 +97,7 @@ func TestValidateStrMaxStringLength(t *testing.T) {
 
 func TestCalculatePoints(t *testing.T) {
 	log.Println("Testing Calculate points in item")
-	cnv := &ItemConverter{false, false, 0, 0, 0}
+	cnv := &ItemConverter{emptyStringsAllowed: false, enforceMaxStringLength: false, maxStringLength: 0, minComments: 0, minPoints: 0}
 
 	for _, test := range storyTests {
 		t.Run(strconv.Itoa(test.id), func(t *testing.T) {
@@ -113,7 +116,7 @@ func TestCalculatePoints(t *testing.T) {
 }
 
 func TestCountComments(t *testing.T) {
-	cnv := &ItemConverter{false, false, 0, 0, 0}
+	cnv := &ItemConverter{emptyStringsAllowed: false, enforceMaxStringLength: false, maxStringLength: 0, minComments: 0, minPoints: 0}
 	log.Println("Testing count comments in item")
 	for _, test := range storyTests {
 		t.Run(strconv.Itoa(test.id), func(t *testing.T) {
@@ -138,7 +141,7 @@ func TestConvertToStory_no_options(t *testing.T) {
 			test := test //capture range variable
 			t.Parallel()
 			item := loadItem(t, test.id)
-			cnv := &ItemConverter{false, false, 0, 0, 0}
+			cnv := &ItemConverter{emptyStringsAllowed: false, enforceMaxStringLength: false, maxStringLength: 0, minComments: 0, minPoints: 0}
 			story, err := cnv.Convert(idx+1, item)
 
 			if err != nil {
@@ -178,7 +181,7 @@ func TestConvertToStory_with_options(t *testing.T) {
 			item := loadItem(t, test.id)
 			stringLength := 256
 
-			cnv := &ItemConverter{false, true, stringLength, 0, 0}
+			cnv := &ItemConverter{emptyStringsAllowed: false, enforceMaxStringLength: true, maxStringLength: stringLength, minComments: 0, minPoints: 0}
 
 			story, err := cnv.Convert(idx+1, item)
 
@@ -209,3 +212,86 @@ func TestConvertToStory_with_options(t *testing.T) {
 		})
 	}
 }
+
+func TestConversionLogger(t *testing.T) {
+	log.Println("Testing rejected items are logged with structured fields")
+
+	out := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	cnv, err := NewItemConverter(DefaultConverterConfig(), WithConversionLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cnv.Convert(3, loadItem(t, 20325925)); err == nil {
+		t.Fatalf("Expected item without a url to be rejected")
+	}
+
+	entry := map[string]interface{}{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("Log line was not json. \n Reason : %s \n %s", err.Error(), out.String())
+	}
+	if entry["story_id"] != float64(20325925) || entry["error_kind"] != "invalid_url" || entry["level"] != "DEBUG" {
+		t.Errorf("Log fields were incorrect. Actual %v", entry)
+	}
+}
+
+func TestConvertCollectsAllErrors(t *testing.T) {
+	log.Println("Testing conversion collects every validation failure")
+
+	item := &RawItem{ID: 1, ItemType: "story", Title: "", By: "someone", URL: "not a url", Score: 0, Descendants: 4}
+
+	// by default only the first failure is returned
+	cfg := DefaultConverterConfig()
+	cfg.Strings = StringOptions{}
+	cnv, err := NewItemConverter(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cnv.Convert(1, item); err != EmptyStringErr {
+		t.Errorf("Expected error was incorrect. \n\t Expected %v got %v", EmptyStringErr, err)
+	}
+
+	cfg.CollectAllErrors = true
+	cnv, err = NewItemConverter(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cnv.Convert(0, item)
+
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("Expected ValidationErrors but got %v", err)
+	}
+
+	expected := []struct {
+		field string
+		rule  string
+	}{
+		{"rank", "min_value"},
+		{"title", "empty_string"},
+		{"url", "invalid_url"},
+		{"points", "min_value"},
+	}
+	if len(verrs) != len(expected) {
+		t.Fatalf("Number of validation errors incorrect. \n\t Expected %d Actual %d \n %v", len(expected), len(verrs), verrs)
+	}
+	for i, test := range expected {
+		if verrs[i].Field != test.field || verrs[i].Rule != test.rule {
+			t.Errorf("Validation error %d incorrect. \n\t Expected %s %s Actual %s %s", i, test.field, test.rule, verrs[i].Field, verrs[i].Rule)
+		}
+	}
+
+	if !errors.Is(err, EmptyStringErr) {
+		t.Errorf("Expected errors.Is to find EmptyStringErr in %v", err)
+	}
+	var urlErr *InvalidURLErr
+	if !errors.As(err, &urlErr) || urlErr.value != "not a url" {
+		t.Errorf("Expected errors.As to find the InvalidURLErr in %v", err)
+	}
+
+	// valid items still convert
+	if _, err := cnv.Convert(1, loadItem(t, 20324021)); err != nil {
+		t.Errorf("Failed to convert story: Reason %s", err.Error())
+	}
+}
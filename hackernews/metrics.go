@@ -0,0 +1,130 @@
+package hackernews
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alis93/hn-scraper/metrics"
+)
+
+// Metrics recorded by a Client
+type ClientMetrics struct {
+	requests *metrics.CounterVec
+	latency  *metrics.HistogramVec
+	retries  *metrics.CounterVec
+}
+
+// Metrics recorded by an ItemConverter
+type ConverterMetrics struct {
+	conversions *metrics.CounterVec
+	failures    *metrics.CounterVec
+}
+
+// Records every request sent through it in the client metrics
+type instrumentedTransport struct {
+	base    http.RoundTripper
+	metrics *ClientMetrics
+}
+
+// Registers the client metrics in registry
+func NewClientMetrics(registry *metrics.Registry) *ClientMetrics {
+	return &ClientMetrics{
+		requests: registry.NewCounterVec("hn_client_requests_total",
+			"Requests sent to the hackernews api by endpoint and status code.", "endpoint", "status"),
+		latency: registry.NewHistogramVec("hn_client_request_duration_seconds",
+			"Latency of requests to the hackernews api by endpoint.", metrics.DefaultBuckets, "endpoint"),
+		retries: registry.NewCounterVec("hn_client_retries_total",
+			"Requests to the hackernews api that were retried by endpoint.", "endpoint"),
+	}
+}
+
+// Registers the converter metrics in registry
+func NewConverterMetrics(registry *metrics.Registry) *ConverterMetrics {
+	return &ConverterMetrics{
+		conversions: registry.NewCounterVec("hn_conversions_total",
+			"Items converted into stories by result.", "result"),
+		failures: registry.NewCounterVec("hn_conversion_failures_total",
+			"Items that failed to convert into stories by error kind.", "error_kind"),
+	}
+}
+
+// Records a single request. status is "error" if no response was received.
+// Safe to call on nil metrics.
+func (m *ClientMetrics) observeRequest(endpoint string, statusCode int, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	status := "error"
+	if statusCode > 0 {
+		status = strconv.Itoa(statusCode)
+	}
+	m.requests.Inc(endpoint, status)
+	m.latency.Observe(elapsed.Seconds(), endpoint)
+}
+
+// Returns a transport sending requests with base and recording each in the metrics,
+// so sources other than Client can record the same metrics
+func (m *ClientMetrics) Instrument(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &instrumentedTransport{base, m}
+}
+
+// Records a retry of a request for the url path. Safe to call on nil metrics.
+func (m *ClientMetrics) ObserveRetry(urlPath string) {
+	m.observeRetry(endpointName(urlPath))
+}
+
+// Records a retry. Safe to call on nil metrics.
+func (m *ClientMetrics) observeRetry(endpoint string) {
+	if m == nil {
+		return
+	}
+	m.retries.Inc(endpoint)
+}
+
+// Records the result of a conversion. Safe to call on nil metrics.
+func (m *ConverterMetrics) observeConversion(err error) {
+	if m == nil {
+		return
+	}
+	if verrs, ok := err.(ValidationErrors); ok {
+		m.conversions.Inc("failure")
+		for _, fieldErr := range verrs {
+			m.failures.Inc(fieldErr.Rule)
+		}
+		return
+	}
+	if err != nil {
+		m.conversions.Inc("failure")
+		m.failures.Inc(ErrorKind(err))
+		return
+	}
+	m.conversions.Inc("success")
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.base.RoundTrip(req)
+
+	statusCode := 0
+	if err == nil {
+		statusCode = res.StatusCode
+	}
+	t.metrics.observeRequest(endpointName(req.URL.Path), statusCode, time.Since(start))
+	return res, err
+}
+
+// Returns the endpoint a url path belongs to, without any ids.
+// For example /v0/item/123.json is item and /v0/topstories.json is topstories
+func endpointName(urlPath string) string {
+	dir, file := path.Split(strings.TrimSuffix(urlPath, ".json"))
+	if parent := path.Base(dir); parent == "item" || parent == "user" {
+		return parent
+	}
+	return file
+}
@@ -0,0 +1,104 @@
+package hackernews
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alis93/hn-scraper/metrics"
+)
+
+func TestEndpointName(t *testing.T) {
+	pathTests := []struct {
+		input    string
+		expected string
+	}{
+		{"/v0/item/20324021.json", "item"},
+		{"/v0/user/pg.json", "user"},
+		{"/v0/topstories.json", "topstories"},
+		{"/maxitem.json", "maxitem"},
+	}
+	for _, test := range pathTests {
+		if name := endpointName(test.input); name != test.expected {
+			t.Errorf("%s failed. \n\t Expected: %s Actual %s ", test.input, test.expected, name)
+		}
+	}
+}
+
+func TestClientMetricsAndRetries(t *testing.T) {
+	log.Println("Testing client retries are recorded in metrics")
+
+	// fail the first two requests
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(helperLoadBytes(t, "item_20324021.json"))
+	}))
+	defer server.Close()
+
+	registry := metrics.NewRegistry()
+	m := NewClientMetrics(registry)
+	client, err := NewClient(5, WithAPIURL(server.URL), WithMetrics(m), WithRetries(RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := client.GetItem(20324021)
+	if err != nil {
+		t.Fatalf("Expected request to succeed after retries. \n Reason: %s", err.Error())
+	}
+	if item.ID != 20324021 {
+		t.Errorf("Wrong item returned. Expected %d Actual %d", 20324021, item.ID)
+	}
+
+	if v := m.requests.Value("item", "503"); v != 2 {
+		t.Errorf("Failed requests incorrect. \n\t Expected %d Actual %v", 2, v)
+	}
+	if v := m.requests.Value("item", "200"); v != 1 {
+		t.Errorf("Successful requests incorrect. \n\t Expected %d Actual %v", 1, v)
+	}
+	if v := m.retries.Value("item"); v != 2 {
+		t.Errorf("Retries incorrect. \n\t Expected %d Actual %v", 2, v)
+	}
+	if count := m.latency.Count("item"); count != 3 {
+		t.Errorf("Latency observations incorrect. \n\t Expected %d Actual %d", 3, count)
+	}
+
+	// out of retries
+	atomic.StoreInt32(&calls, 0)
+	client, _ = NewClient(5, WithAPIURL(server.URL), WithRetries(RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond}))
+	if _, err := client.GetItem(20324021); err == nil {
+		t.Errorf("Expected request to fail once retries ran out")
+	}
+}
+
+func TestConverterMetrics(t *testing.T) {
+	log.Println("Testing conversion failures are recorded in metrics")
+
+	registry := metrics.NewRegistry()
+	m := NewConverterMetrics(registry)
+	cnv, err := NewItemConverter(DefaultConverterConfig(), WithConversionMetrics(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cnv.Convert(1, loadItem(t, 20324021))
+	cnv.Convert(2, loadItem(t, 20325925)) // ask hn has no url
+	cnv.Convert(3, &RawItem{ItemType: "comment"})
+
+	if v := m.conversions.Value("success"); v != 1 {
+		t.Errorf("Successful conversions incorrect. \n\t Expected %d Actual %v", 1, v)
+	}
+	if v := m.failures.Value("invalid_url"); v != 1 {
+		t.Errorf("invalid_url failures incorrect. \n\t Expected %d Actual %v", 1, v)
+	}
+	if v := m.failures.Value("invalid_type"); v != 1 {
+		t.Errorf("invalid_type failures incorrect. \n\t Expected %d Actual %v", 1, v)
+	}
+}
@@ -0,0 +1,45 @@
+package hackernews
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// Longest line ReadStories accepts
+const MAX_LINE_BYTES = 1 << 20
+
+// Writes each story as a single line of json
+func WriteStories(w io.Writer, stories []*Story) error {
+	encoder := json.NewEncoder(w)
+	for _, story := range stories {
+		if story == nil {
+			continue
+		}
+		if err := encoder.Encode(story); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reads stories written by WriteStories, one json object per line.
+// Blank lines are skipped. Returns *LineErr if a line is not a story
+func ReadStories(r io.Reader) ([]*Story, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), MAX_LINE_BYTES)
+
+	stories := []*Story{}
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		story := &Story{}
+		if err := json.Unmarshal(scanner.Bytes(), story); err != nil {
+			return nil, &LineErr{line, err}
+		}
+		stories = append(stories, story)
+	}
+	return stories, scanner.Err()
+}
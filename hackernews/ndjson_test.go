@@ -0,0 +1,47 @@
+package hackernews
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStoriesRoundTrip(t *testing.T) {
+	log.Println("Testing writing and reading stories as NDJSON")
+
+	stories := []*Story{
+		{ID: 1, Title: "First", URL: "https://example.com/1", Points: 10, Rank: 1},
+		nil,
+		{ID: 2, Title: "Second\nline", URL: "https://example.com/2", Rank: 2, DuplicateOf: []int{3}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteStories(&buf, stories); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 2 {
+		t.Errorf("Expected 2 lines but got %d", lines)
+	}
+
+	read, err := ReadStories(&buf)
+	if err != nil {
+		t.Fatalf("Failed to read stories. Reason : %s", err.Error())
+	}
+	expected := []*Story{stories[0], stories[2]}
+	if !cmp.Equal(read, expected) {
+		t.Errorf("Stories incorrect. \n\t %s", cmp.Diff(expected, read))
+	}
+}
+
+func TestReadStoriesInvalidLine(t *testing.T) {
+	input := "{\"id\": 1}\n\n{\"id\": \"two\"}\n"
+	_, err := ReadStories(strings.NewReader(input))
+	var lineErr *LineErr
+	if !errors.As(err, &lineErr) || lineErr.line != 3 {
+		t.Errorf("Expected *LineErr on line 3 but got %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+package hackernews
+
+// Steps a story goes through before it can be printed
+const (
+	STAGE_FETCH   = "fetch"
+	STAGE_CONVERT = "convert"
+)
+
+// The outcome of retrieving and converting a single story.
+// Story is set if Err is nil, otherwise Stage is the step that failed
+type Result struct {
+	Rank int
+	ID   int
+	// The item read from the source, nil if it could not be retrieved
+	Item  *RawItem
+	Story *Story
+	Err   error
+	Stage string
+}
+
+// Returns true if the story could not be retrieved or converted
+func (r Result) Failed() bool {
+	return r.Err != nil
+}
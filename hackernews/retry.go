@@ -0,0 +1,57 @@
+package hackernews
+
+import (
+	"net/http"
+	"time"
+)
+
+// How failed requests are retried.
+// The zero value never retries.
+type RetryPolicy struct {
+	MaxRetries int
+	// Wait before the first retry. Doubled after each retry
+	Backoff time.Duration
+}
+
+// Returns true if a request that got res or err is worth sending again.
+// Network errors, 429 and 5xx responses are retried.
+func retryable(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+}
+
+// Sends the request using hc, retrying according to the policy.
+// onRetry, if not nil, is called with the failed response or error before each retry.
+// A request with a body must set GetBody, as http.NewRequest does for bytes and strings readers,
+// so the body can be sent again.
+func (p RetryPolicy) Do(hc *http.Client, req *http.Request, onRetry func(res *http.Response, err error)) (*http.Response, error) {
+	wait := p.Backoff
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		res, err := hc.Do(req)
+		if attempt >= p.MaxRetries || !retryable(res, err) {
+			return res, err
+		}
+		if onRetry != nil {
+			onRetry(res, err)
+		}
+		if res != nil {
+			res.Body.Close()
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		wait *= 2
+	}
+}
@@ -0,0 +1,14 @@
+package hackernews
+
+import "context"
+
+// Where story ids and items are read from.
+// Client reads them from the hackernews api
+type Source interface {
+	// Returns the first n story ids of list in rank order
+	ListIDs(ctx context.Context, list string, n int) ([]int, error)
+	// Returns the item with the id
+	Item(ctx context.Context, id int) (*RawItem, error)
+}
+
+var _ Source = Client{}
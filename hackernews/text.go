@@ -0,0 +1,138 @@
+package hackernews
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	paragraphPattern = regexp.MustCompile(`(?i)<p>`)
+	tagPattern       = regexp.MustCompile(`<[^>]*>`)
+)
+
+// How strings are cleaned before they are validated.
+// The zero value leaves strings as they are, apart from replacing invalid UTF-8.
+type StringOptions struct {
+	// Appended to truncated strings, counted within the max length. Left out if it does not fit
+	Ellipsis string `json:"ellipsis" yaml:"ellipsis" toml:"ellipsis"`
+	// Replaces tabs and newlines with spaces and removes every other control character
+	StripControl bool `json:"stripControl" yaml:"stripControl" toml:"stripControl"`
+	// Normalises strings to Unicode NFC, so the same text is always the same bytes
+	NormalizeNFC bool `json:"normalizeNFC" yaml:"normalizeNFC" toml:"normalizeNFC"`
+	// Decodes HTML entities such as &amp; and &#x27;
+	DecodeHTMLEntities bool `json:"decodeHTMLEntities" yaml:"decodeHTMLEntities" toml:"decodeHTMLEntities"`
+}
+
+// Cleans every string. Used by the command line and server.
+var DefaultStringOptions = StringOptions{
+	Ellipsis:           "…",
+	StripControl:       true,
+	NormalizeNFC:       true,
+	DecodeHTMLEntities: true,
+}
+
+// Applies the options to str. The result is always valid UTF-8
+func (opts StringOptions) clean(str string) string {
+	str = strings.ToValidUTF8(str, string(utf8.RuneError))
+
+	if opts.DecodeHTMLEntities {
+		str = html.UnescapeString(str)
+	}
+	if opts.StripControl {
+		str = stripControl(str)
+	}
+	if opts.NormalizeNFC {
+		str = norm.NFC.String(str)
+	}
+	return str
+}
+
+// Replaces tabs and newlines with spaces and removes other control characters
+func stripControl(str string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			return ' '
+		case unicode.IsControl(r):
+			return -1
+		}
+		return r
+	}, str)
+}
+
+// Shortens str to at most maxBytes bytes, ending with ellipsis if it fits.
+// Only cuts between characters, so multi-byte runes and combining marks are never split
+func truncate(str string, maxBytes int, ellipsis string) string {
+	if len(str) <= maxBytes {
+		return str
+	}
+
+	budget := maxBytes - len(ellipsis)
+	if budget < 0 {
+		budget, ellipsis = maxBytes, ""
+	}
+
+	cut := 0
+	for i, r := range str {
+		if i > budget {
+			break
+		}
+		if isBoundary(str, i, r) {
+			cut = i
+		}
+	}
+
+	return strings.TrimRightFunc(str[:cut], unicode.IsSpace) + ellipsis
+}
+
+// Returns true if str can be cut before the rune r at byte i without splitting a character.
+// This approximates grapheme cluster boundaries: a cut is not allowed before combining marks,
+// joiners, variation selectors and skin tone modifiers, straight after a zero width joiner,
+// or between the two regional indicators of a flag.
+func isBoundary(str string, i int, r rune) bool {
+	if i == 0 {
+		return true
+	}
+	if isRegionalIndicator(r) && precedingRegionalIndicators(str[:i])%2 == 1 {
+		return false
+	}
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		r == '\u200d' ||
+		unicode.Is(unicode.Variation_Selector, r) ||
+		(r >= 0x1f3fb && r <= 0x1f3ff) {
+		return false
+	}
+	prev, _ := utf8.DecodeLastRuneInString(str[:i])
+	return prev != '\u200d'
+}
+
+// Flags are pairs of regional indicator letters
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// Returns how many regional indicators str ends with
+func precedingRegionalIndicators(str string) int {
+	count := 0
+	for len(str) > 0 {
+		r, size := utf8.DecodeLastRuneInString(str)
+		if !isRegionalIndicator(r) {
+			break
+		}
+		count++
+		str = str[:len(str)-size]
+	}
+	return count
+}
+
+// Converts the html of comments and story text to plain text.
+// Hackernews separates paragraphs with <p>, which become new lines
+func PlainText(s string) string {
+	s = paragraphPattern.ReplaceAllString(s, "\n")
+	return strings.TrimSpace(html.UnescapeString(tagPattern.ReplaceAllString(s, "")))
+}
@@ -0,0 +1,102 @@
+package hackernews
+
+import (
+	"log"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	log.Println("Testing truncating strings on character boundaries")
+
+	truncateTests := []struct {
+		input    string
+		maxBytes int
+		ellipsis string
+		expected string
+	}{
+		{"short", 10, "", "short"},
+		{"A really long string thats too long", 20, "", "A really long string"},
+		{"A really long string thats too long", 20, "…", "A really long str…"},
+		// ’ is 3 bytes starting at byte 6, so cutting at 7 or 8 must back off to 6
+		{"Google’s robots.txt", 7, "", "Google"},
+		{"Google’s robots.txt", 8, "", "Google"},
+		{"Google’s robots.txt", 9, "", "Google’"},
+		// e followed by a combining acute accent is one character
+		{"cafe\u0301 au lait", 5, "", "caf"},
+		// family emoji joined with zero width joiners is one character
+		{"hi \U0001F468\u200d\U0001F469\u200d\U0001F467 there", 10, "", "hi"},
+		// skin tone modifiers stay with their emoji
+		{"thumbs \U0001F44D\U0001F3FD up", 11, "", "thumbs"},
+		// flags are pairs of regional indicators, so the cut must not land inside the second flag
+		{"\U0001F1EF\U0001F1F5\U0001F1FA\U0001F1F8 abc", 12, "", "\U0001F1EF\U0001F1F5"},
+		{"\U0001F1EF\U0001F1F5\U0001F1FA\U0001F1F8 abc", 16, "", "\U0001F1EF\U0001F1F5\U0001F1FA\U0001F1F8"},
+		// ellipsis longer than the limit is left out
+		{"abcdef", 2, "…", "ab"},
+	}
+
+	for _, test := range truncateTests {
+		t.Run(test.input, func(t *testing.T) {
+			truncated := truncate(test.input, test.maxBytes, test.ellipsis)
+			if truncated != test.expected {
+				t.Errorf("Truncated string was incorrect. \n\t Expected %q got %q", test.expected, truncated)
+			}
+			if len(truncated) > test.maxBytes {
+				t.Errorf("Truncated string is %d bytes, more than max of %d", len(truncated), test.maxBytes)
+			}
+			if !utf8.ValidString(truncated) {
+				t.Errorf("Truncated string %q is not valid UTF-8", truncated)
+			}
+		})
+	}
+}
+
+func TestStringOptionsClean(t *testing.T) {
+	log.Println("Testing cleaning strings")
+
+	cleanTests := []struct {
+		name     string
+		opts     StringOptions
+		input    string
+		expected string
+	}{
+		{"invalid utf8 always replaced", StringOptions{}, "bad \xff byte", "bad � byte"},
+		{"entities left alone", StringOptions{}, "Q&amp;A", "Q&amp;A"},
+		{"entities decoded", StringOptions{DecodeHTMLEntities: true}, "Q&amp;A &#x27;quoted&#x27; &lt;b&gt;", "Q&A 'quoted' <b>"},
+		{"control stripped", StringOptions{StripControl: true}, "line\none\ttab\x00\x1bnull", "line one tabnull"},
+		{"nfc", StringOptions{NormalizeNFC: true}, "cafe\u0301", "caf\u00e9"},
+		{"defaults", DefaultStringOptions, "Caf&eacute;s\n&amp; cafe\u0301s", "Caf\u00e9s & caf\u00e9s"},
+	}
+
+	for _, test := range cleanTests {
+		t.Run(test.name, func(t *testing.T) {
+			if cleaned := test.opts.clean(test.input); cleaned != test.expected {
+				t.Errorf("Cleaned string was incorrect. \n\t Expected %q got %q", test.expected, cleaned)
+			}
+		})
+	}
+}
+
+func TestValidateStrWithStringOptions(t *testing.T) {
+	log.Println("Testing validating strings with string options")
+
+	cfg := DefaultConverterConfig()
+	cfg.MaxStringLength = 16
+	cnv, err := NewItemConverter(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validated, err := cnv.ValidateStr("Google&#8217;s robots.txt parser")
+	if err != nil {
+		t.Fatalf("Failed to validate string. Reason : %s", err.Error())
+	}
+	if validated != "Google’s ro…" {
+		t.Errorf("Validated string was incorrect. \n\t Expected %q got %q", "Google’s ro…", validated)
+	}
+
+	// a string that is empty once cleaned is still rejected
+	if _, err := cnv.ValidateStr("\x00\x01"); err != EmptyStringErr {
+		t.Errorf("Expected error was incorrect. \n\t Expected %v got %v", EmptyStringErr, err)
+	}
+}
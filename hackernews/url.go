@@ -0,0 +1,139 @@
+package hackernews
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Rules a story url must follow
+type URLPolicy struct {
+	// Lowercase schemes that are allowed, for example http and https
+	AllowedSchemes []string `json:"allowedSchemes" yaml:"allowedSchemes" toml:"allowedSchemes"`
+	// Rejects urls without a host, such as bare paths and mailto:
+	RequireHost bool `json:"requireHost" yaml:"requireHost" toml:"requireHost"`
+	// Rejects hosts that are IP addresses rather than domain names
+	RejectIPLiterals bool `json:"rejectIPLiterals" yaml:"rejectIPLiterals" toml:"rejectIPLiterals"`
+	// Rejects localhost and IP addresses in loopback, private, link local or unspecified ranges
+	RejectPrivate bool `json:"rejectPrivate" yaml:"rejectPrivate" toml:"rejectPrivate"`
+}
+
+// Only allows absolute http and https urls
+var DefaultURLPolicy = URLPolicy{
+	AllowedSchemes: []string{"http", "https"},
+	RequireHost:    true,
+}
+
+// Query parameters removed by CanonicalizeURL. Parameters starting with utm_ are also removed.
+var TrackingParams = []string{"ref", "ref_src", "fbclid", "gclid", "mc_cid", "mc_eid"}
+
+// Returns *InvalidURLErr if rawURL does not follow the policy
+func (p URLPolicy) Validate(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || rawURL == "" {
+		return &InvalidURLErr{rawURL, "not a url"}
+	}
+	// url.Parse accepts any bytes in the host, which would leave invalid UTF-8 in the story and its domain
+	if !utf8.ValidString(rawURL) {
+		return &InvalidURLErr{rawURL, "not valid UTF-8"}
+	}
+
+	if !p.allowsScheme(strings.ToLower(u.Scheme)) {
+		return &InvalidURLErr{rawURL, "scheme " + u.Scheme + " is not allowed"}
+	}
+
+	host := u.Hostname()
+	if p.RequireHost && host == "" {
+		return &InvalidURLErr{rawURL, "no host"}
+	}
+
+	ip := net.ParseIP(host)
+	if p.RejectIPLiterals && ip != nil {
+		return &InvalidURLErr{rawURL, "host is an IP address"}
+	}
+	if p.RejectPrivate && isPrivateHost(host, ip) {
+		return &InvalidURLErr{rawURL, "host is private"}
+	}
+
+	return nil
+}
+
+func (p URLPolicy) allowsScheme(scheme string) bool {
+	if scheme == "" {
+		return false
+	}
+	for _, allowed := range p.AllowedSchemes {
+		if scheme == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if host is localhost or ip is not publicly routable
+func isPrivateHost(host string, ip net.IP) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	if ip == nil {
+		return false
+	}
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified()
+}
+
+// Returns a canonical form of rawURL so the same page submitted with different urls compares equal.
+// Lowercases the scheme and host, removes default ports, fragments and tracking parameters,
+// sorts the remaining query parameters and uses / for an empty path.
+func CanonicalizeURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "" && u.Host != "" {
+		u.Path = "/"
+	}
+
+	query := u.Query()
+	for param := range query {
+		if isTrackingParam(param) {
+			query.Del(param)
+		}
+	}
+	u.RawQuery = query.Encode()
+	u.ForceQuery = false
+
+	return u.String(), nil
+}
+
+func isTrackingParam(param string) bool {
+	param = strings.ToLower(param)
+	if strings.HasPrefix(param, "utm_") {
+		return true
+	}
+	for _, tracking := range TrackingParams {
+		if param == tracking {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package hackernews
+
+import (
+	"log"
+	"testing"
+)
+
+func TestURLPolicyValidate(t *testing.T) {
+	log.Println("Testing url policies")
+
+	strict := URLPolicy{
+		AllowedSchemes:   []string{"https"},
+		RequireHost:      true,
+		RejectIPLiterals: true,
+		RejectPrivate:    true,
+	}
+	private := URLPolicy{AllowedSchemes: []string{"http", "https"}, RequireHost: true, RejectPrivate: true}
+
+	policyTests := []struct {
+		name     string
+		policy   URLPolicy
+		input    string
+		expected bool
+	}{
+		{"default http", DefaultURLPolicy, "http://natpryce.com/articles/000819.html", true},
+		{"default ip", DefaultURLPolicy, "http://93.184.216.34/page", true},
+		{"default mailto", DefaultURLPolicy, "mailto:someone@example.com", false},
+		{"default invalid utf8", DefaultURLPolicy, "http://\xa2", false},
+		{"strict https", strict, "https://opensource.googleblog.com/", true},
+		{"strict http", strict, "http://opensource.googleblog.com/", false},
+		{"strict ip", strict, "https://93.184.216.34/page", false},
+		{"private public ip", private, "http://93.184.216.34/page", true},
+		{"private loopback", private, "http://127.0.0.1:8080/admin", false},
+		{"private range", private, "http://192.168.1.1/", false},
+		{"private ipv6 loopback", private, "http://[::1]/", false},
+		{"private localhost", private, "http://localhost:3000/", false},
+		{"private link local", private, "http://169.254.169.254/latest/meta-data", false},
+	}
+
+	for _, test := range policyTests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.policy.Validate(test.input)
+			if test.expected && err != nil {
+				t.Errorf("%s should be valid but got %s", test.input, err.Error())
+			}
+			if !test.expected {
+				if _, ok := err.(*InvalidURLErr); !ok {
+					t.Errorf("%s should be invalid with *InvalidURLErr but got %v", test.input, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCanonicalizeURL(t *testing.T) {
+	log.Println("Testing url canonicalisation")
+
+	canonicalTests := []struct {
+		input    string
+		expected string
+	}{
+		{"http://natpryce.com/articles/000819.html", "http://natpryce.com/articles/000819.html"},
+		{"HTTPS://OpenSource.GoogleBlog.com:443/2019/07/post.html#comments", "https://opensource.googleblog.com/2019/07/post.html"},
+		{"http://example.com:80", "http://example.com/"},
+		{"http://example.com:8080/a", "http://example.com:8080/a"},
+		{"https://example.com/a?utm_source=hn&utm_medium=social&id=5&ref=hackernews", "https://example.com/a?id=5"},
+		{"https://example.com/a?b=2&a=1&fbclid=xyz", "https://example.com/a?a=1&b=2"},
+		{"https://example.com/a?", "https://example.com/a"},
+		{"https://example.com./a", "https://example.com/a"},
+	}
+
+	for _, test := range canonicalTests {
+		t.Run(test.input, func(t *testing.T) {
+			canonical, err := CanonicalizeURL(test.input)
+			if err != nil {
+				t.Fatalf("Failed to canonicalise %s. Reason : %s", test.input, err.Error())
+			}
+			if canonical != test.expected {
+				t.Errorf("Canonical url incorrect. \n\t Expected %s Actual %s", test.expected, canonical)
+			}
+		})
+	}
+}
+
+func TestConvertSetsCanonicalURL(t *testing.T) {
+	log.Println("Testing conversion stores raw and canonical urls")
+
+	item := loadItem(t, 20324021)
+	item.URL = "HTTP://NatPryce.com/articles/000819.html?utm_source=hn#top"
+
+	cnv, _ := NewItemConverter(DefaultConverterConfig())
+	story, err := cnv.Convert(1, item)
+	if err != nil {
+		t.Fatalf("Failed to convert story: Reason %s", err.Error())
+	}
+	if story.URL != item.URL {
+		t.Errorf("Raw url incorrect. \n\t Expected %s Actual %s", item.URL, story.URL)
+	}
+	if story.CanonicalURL != "http://natpryce.com/articles/000819.html" {
+		t.Errorf("Canonical url incorrect. \n\t Expected %s Actual %s", "http://natpryce.com/articles/000819.html", story.CanonicalURL)
+	}
+
+	// a stricter policy rejects the http url
+	cfg := DefaultConverterConfig()
+	cfg.URLPolicy = URLPolicy{AllowedSchemes: []string{"https"}, RequireHost: true}
+	cnv, _ = NewItemConverter(cfg)
+	if _, err := cnv.Convert(1, item); ErrorKind(err) != "invalid_url" {
+		t.Errorf("Expected http url to be rejected but got %v", err)
+	}
+}
@@ -12,7 +12,13 @@ var urlTests = []struct {
 	{"https://bbc.com", true},
 	{"https://bbc.com/dklfjsdf", true},
 	{"https://www.google.com/search?q=fdjskfd&oq", true},
-	{"mongodb://mongodb0.example.com:27017/admin", true},
+	{"HTTPS://BBC.com", true},
+	{"mongodb://mongodb0.example.com:27017/admin", false},
+	{"ftp://files.example.com/file.txt", false},
+	{"javascript:alert(1)", false},
+	{"/just/a/path", false},
+	{"http:///no-host", false},
+	{"htps://www.bbc.com/news", false},
 	{"", false},
 	{"Hello how are you", false},
 }
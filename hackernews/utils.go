@@ -1,15 +1,24 @@
 package hackernews
 
-import "net/url"
+import (
+	"log/slog"
+)
 
 // file to hold utility functions
 
+// Used when no logger has been given
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// Returns l, or a logger that discards everything if l is nil
+func logger(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return discardLogger
+	}
+	return l
+}
+
 // Returns true if the passed string is a valid url.
-// Only allows HTTP or HTTPS
+// Only allows absolute HTTP or HTTPS urls, see DefaultURLPolicy
 func isValidURLScheme(testURL string) bool {
-	_, err := url.ParseRequestURI(testURL)
-	if err != nil {
-		return false
-	}
-	return true
+	return DefaultURLPolicy.Validate(testURL) == nil
 }
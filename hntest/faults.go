@@ -0,0 +1,170 @@
+package hntest
+
+import (
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Routes faults can be injected into. A fault can also be injected into an exact path such as /v0/item/1.json
+const (
+	ROUTE_ANY      = "*"
+	ROUTE_LIST     = "list"
+	ROUTE_ITEM     = "item"
+	ROUTE_USER     = "user"
+	ROUTE_MAX_ITEM = "maxitem"
+)
+
+// A way the server misbehaves on a route.
+// Faults can be combined, for example latency followed by a truncated body
+type Fault struct {
+	// Fraction of matching requests the fault applies to, chosen with the server's seeded random numbers.
+	// 0 applies it to every request
+	Rate float64
+	// Only the first Times matching requests the fault applies to are affected. 0 means no limit
+	Times int
+
+	// Wait before responding
+	Latency time.Duration
+	// Respond with this status code and an error body
+	Status int
+	// Close the connection without responding, as a crashed server or proxy would
+	Reset bool
+	// Respond with null, as the api does for items that do not exist
+	Null bool
+	// Send only this many bytes of the body while promising all of it in Content-Length
+	Truncate int
+	// Send the body one byte at a time, waiting this long before each byte
+	Drip time.Duration
+}
+
+// A fault and how many more times it may apply
+type injected struct {
+	route string
+	fault Fault
+	left  int
+}
+
+// Seeds the random numbers that decide which requests a fault with a Rate applies to.
+// Defaults to 1, so a test sees the same failures every run
+func WithSeed(seed int64) Option {
+	return func(s *Server) {
+		s.random = rand.New(rand.NewSource(seed))
+	}
+}
+
+// Makes the server misbehave on route, one of the ROUTE_ constants or an exact path.
+// Faults are checked in the order they were injected and the first that applies is used
+func (s *Server) Inject(route string, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &injected{route, fault, fault.Times})
+}
+
+// Removes every injected fault
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// Returns the route name of a path, such as item for /v0/item/1.json
+func routeOf(path string) string {
+	switch {
+	case listPattern.MatchString(path):
+		return ROUTE_LIST
+	case itemPattern.MatchString(path):
+		return ROUTE_ITEM
+	case userPattern.MatchString(path):
+		return ROUTE_USER
+	case path == "/v0/"+MAX_ITEM_FILE:
+		return ROUTE_MAX_ITEM
+	}
+	return ""
+}
+
+// Returns the fault that applies to a request for path, if any, using up one of its Times
+func (s *Server) faultFor(path string) (Fault, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	route := routeOf(path)
+	for _, f := range s.faults {
+		if f.route != ROUTE_ANY && f.route != route && f.route != path {
+			continue
+		}
+		if f.fault.Times > 0 && f.left == 0 {
+			continue
+		}
+		if f.fault.Rate > 0 && s.random.Float64() >= f.fault.Rate {
+			continue
+		}
+		f.left--
+		return f.fault, true
+	}
+	return Fault{}, false
+}
+
+// Writes body as the response to r, misbehaving as the fault says. The zero Fault writes it normally
+func (f Fault) apply(w http.ResponseWriter, r *http.Request, body []byte) {
+	if f.Latency > 0 {
+		select {
+		case <-time.After(f.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if f.Reset {
+		reset(w)
+		return
+	}
+	if f.Status != 0 {
+		http.Error(w, http.StatusText(f.Status), f.Status)
+		return
+	}
+	if f.Null {
+		body = []byte("null")
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if f.Truncate > 0 && f.Truncate < len(body) {
+		body = body[:f.Truncate]
+	}
+
+	if f.Drip <= 0 {
+		w.Write(body)
+		return
+	}
+	flusher, _ := w.(http.Flusher)
+	for i := range body {
+		select {
+		case <-time.After(f.Drip):
+		case <-r.Context().Done():
+			return
+		}
+		w.Write(body[i : i+1])
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// Closes the connection of w straight away, sending a TCP reset where possible
+func reset(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.SetLinger(0)
+	}
+	conn.Close()
+}
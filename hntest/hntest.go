@@ -0,0 +1,195 @@
+package hntest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+// Fixture file names, the same layout offline.DirSource reads
+const (
+	LIST_FILE     = "%sstories.json"
+	ITEM_FILE     = "item_%d.json"
+	USER_FILE     = "user_%s.json"
+	MAX_ITEM_FILE = "maxitem.json"
+	// Set to an api url such as https://hacker-news.firebaseio.com to record fixtures from it instead of serving them
+	RECORD_ENV = "HNTEST_RECORD"
+)
+
+var (
+	listPattern = regexp.MustCompile(`^/v0/([a-z]+)stories\.json$`)
+	itemPattern = regexp.MustCompile(`^/v0/item/([0-9]+)\.json$`)
+	userPattern = regexp.MustCompile(`^/v0/user/([A-Za-z0-9_-]+)\.json$`)
+)
+
+// A fake hackernews api serving fixtures, for tests.
+// Missing items, users and lists are answered with null, as the real api does.
+// This package does not import hackernews, so that package's own tests can use it
+type Server struct {
+	*httptest.Server
+	dir      string
+	upstream string
+
+	mu        sync.Mutex
+	overrides map[string][]byte
+	requests  map[string]int
+	faults    []*injected
+	random    *rand.Rand
+}
+
+// Optional settings applied when creating a server
+type Option func(*Server)
+
+// Forwards every request to the api at upstream and saves each response as a fixture in dir.
+// NewServer records from the url in RECORD_ENV if it is set
+func WithRecording(upstream string) Option {
+	return func(s *Server) {
+		s.upstream = upstream
+	}
+}
+
+// Starts a server serving the fixtures in dir. It is closed when the test ends
+func NewServer(t testing.TB, dir string, opts ...Option) *Server {
+	s := &Server{
+		dir:       dir,
+		upstream:  os.Getenv(RECORD_ENV),
+		overrides: make(map[string][]byte),
+		requests:  make(map[string]int),
+		random:    rand.New(rand.NewSource(1)),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// The url to create a client with, including the api version
+func (s *Server) APIURL() string {
+	return s.URL + "/v0"
+}
+
+// Returns how many requests were made for path, such as /v0/item/1.json
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// Serves item as the item with the id instead of its fixture. A nil item is served as null
+func (s *Server) SetItem(id int, item interface{}) {
+	s.set(fmt.Sprintf(ITEM_FILE, id), item)
+}
+
+// Serves ids as the list instead of its fixture
+func (s *Server) SetList(list string, ids []int) {
+	s.set(fmt.Sprintf(LIST_FILE, list), ids)
+}
+
+// Serves id as the newest item id instead of the fixture
+func (s *Server) SetMaxItem(id int) {
+	s.set(MAX_ITEM_FILE, id)
+}
+
+func (s *Server) set(name string, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overrides[name] = body
+}
+
+// Returns the fixture file name for a request path, or an empty string if the api has no such path
+func fixtureName(path string) string {
+	if match := listPattern.FindStringSubmatch(path); match != nil {
+		return fmt.Sprintf(LIST_FILE, match[1])
+	}
+	if match := itemPattern.FindStringSubmatch(path); match != nil {
+		return "item_" + match[1] + ".json"
+	}
+	if match := userPattern.FindStringSubmatch(path); match != nil {
+		return fmt.Sprintf(USER_FILE, match[1])
+	}
+	if path == "/v0/"+MAX_ITEM_FILE {
+		return MAX_ITEM_FILE
+	}
+	return ""
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests[r.URL.Path]++
+	s.mu.Unlock()
+
+	name := fixtureName(r.URL.Path)
+	if name == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	var body []byte
+	var err error
+	if s.upstream != "" {
+		body, err = s.record(r.URL.Path, name)
+	} else {
+		body, err = s.fixture(name)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	fault, _ := s.faultFor(r.URL.Path)
+	fault.apply(w, r, body)
+}
+
+// Returns the override or fixture called name, or null if there is neither
+func (s *Server) fixture(name string) ([]byte, error) {
+	s.mu.Lock()
+	body, ok := s.overrides[name]
+	s.mu.Unlock()
+	if ok {
+		return body, nil
+	}
+
+	body, err := ioutil.ReadFile(filepath.Join(s.dir, name))
+	if os.IsNotExist(err) {
+		return []byte("null"), nil
+	}
+	return body, err
+}
+
+// Requests path from upstream and saves the response as the fixture called name
+func (s *Server) record(path, name string) ([]byte, error) {
+	res, err := http.Get(s.upstream + path)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("recording %s failed with status code %d", path, res.StatusCode)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(s.dir, name), body, 0644); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,212 @@
+package hntest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const FIXTURES = "../hackernews/testdata"
+
+func helperGet(t *testing.T, url string) (int, string) {
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := ioutil.ReadAll(res.Body)
+	return res.StatusCode, strings.TrimSpace(string(body))
+}
+
+func TestServer(t *testing.T) {
+	log.Println("Testing the fake api")
+
+	t.Setenv(RECORD_ENV, "")
+	server := NewServer(t, FIXTURES)
+
+	tests := []struct {
+		path     string
+		status   int
+		contains string
+	}{
+		{"/topstories.json", http.StatusOK, "[20325395, 20328871, 20325925, 20329699, 20324021]"},
+		{"/item/20324021.json", http.StatusOK, `"by": "moks"`},
+		{"/item/20329699.json", http.StatusOK, `"id": 20329699`},
+		{"/user/moks.json", http.StatusOK, `"karma": 1023`},
+		{"/maxitem.json", http.StatusOK, "20330835"},
+		// missing fixtures are null, as the real api answers for unknown ids
+		{"/item/1.json", http.StatusOK, "null"},
+		{"/newstories.json", http.StatusOK, "null"},
+		{"/item/abc.json", http.StatusNotFound, ""},
+		{"/updates.json", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		status, body := helperGet(t, server.APIURL()+test.path)
+		if status != test.status || !strings.Contains(body, test.contains) {
+			t.Errorf("%s incorrect. Expected %d %q Actual %d %q", test.path, test.status, test.contains, status, body)
+		}
+	}
+	if n := server.Requests("/v0/item/20324021.json"); n != 1 {
+		t.Errorf("Expected 1 request for the item but got %d", n)
+	}
+}
+
+func TestServerOverrides(t *testing.T) {
+	log.Println("Testing overriding fixtures")
+
+	t.Setenv(RECORD_ENV, "")
+	server := NewServer(t, FIXTURES)
+	server.SetList("new", []int{3, 2, 1})
+	server.SetItem(3, map[string]interface{}{"id": 3, "type": "story"})
+	server.SetItem(20324021, nil)
+
+	tests := map[string]string{
+		"/newstories.json":    "[3,2,1]",
+		"/item/3.json":        `{"id":3,"type":"story"}`,
+		"/item/20324021.json": "null",
+	}
+	for path, expected := range tests {
+		if _, body := helperGet(t, server.APIURL()+path); body != expected {
+			t.Errorf("%s incorrect. Expected %q Actual %q", path, expected, body)
+		}
+	}
+}
+
+func TestServerRecording(t *testing.T) {
+	log.Println("Testing recording fixtures")
+
+	t.Setenv(RECORD_ENV, "")
+	live := NewServer(t, FIXTURES)
+	dir := t.TempDir()
+	recorder := NewServer(t, dir, WithRecording(live.URL))
+
+	for _, path := range []string{"/topstories.json", "/item/20324021.json", "/user/moks.json"} {
+		if status, _ := helperGet(t, recorder.APIURL()+path); status != http.StatusOK {
+			t.Fatalf("Failed to record %s. Status %d", path, status)
+		}
+	}
+
+	// the recorded fixtures are served once recording stops
+	replay := NewServer(t, dir)
+	for _, name := range []string{"topstories.json", "item_20324021.json", "user_moks.json"} {
+		expected, _ := ioutil.ReadFile(filepath.Join(FIXTURES, name))
+		recorded, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Fixture %s was not recorded", name)
+		}
+		if string(recorded) != string(expected) {
+			t.Errorf("Fixture %s differs from the live response", name)
+		}
+	}
+	if _, body := helperGet(t, replay.APIURL()+"/item/20324021.json"); !strings.Contains(body, `"by": "moks"`) {
+		t.Errorf("Recorded item not served. %q", body)
+	}
+	if live.Requests("/v0/item/20324021.json") != 1 {
+		t.Errorf("Expected the replayed item not to reach the live api")
+	}
+}
+
+func TestFaults(t *testing.T) {
+	log.Println("Testing injected faults")
+
+	t.Setenv(RECORD_ENV, "")
+	client := &http.Client{Timeout: 200 * time.Millisecond}
+
+	tests := []struct {
+		name   string
+		route  string
+		fault  Fault
+		path   string
+		status int
+		body   string
+		err    bool
+	}{
+		{"status", ROUTE_ITEM, Fault{Status: http.StatusServiceUnavailable}, "/item/20324021.json", http.StatusServiceUnavailable, "Service Unavailable", false},
+		{"null", ROUTE_ITEM, Fault{Null: true}, "/item/20324021.json", http.StatusOK, "null", false},
+		{"other route", ROUTE_LIST, Fault{Null: true}, "/item/20324021.json", http.StatusOK, `"by": "moks"`, false},
+		{"exact path", "/v0/item/20324021.json", Fault{Status: http.StatusTooManyRequests}, "/item/20324021.json", http.StatusTooManyRequests, "", false},
+		{"reset", ROUTE_ANY, Fault{Reset: true}, "/topstories.json", 0, "", true},
+		{"truncate", ROUTE_LIST, Fault{Truncate: 10}, "/topstories.json", http.StatusOK, "", true},
+		{"latency", ROUTE_LIST, Fault{Latency: time.Second}, "/topstories.json", 0, "", true},
+		{"drip", ROUTE_ITEM, Fault{Drip: 50 * time.Millisecond}, "/item/20324021.json", http.StatusOK, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := NewServer(t, FIXTURES)
+			server.Inject(test.route, test.fault)
+
+			res, err := client.Get(server.APIURL() + test.path)
+			if err == nil {
+				defer res.Body.Close()
+				var body []byte
+				body, err = ioutil.ReadAll(res.Body)
+				if err == nil && (res.StatusCode != test.status || !strings.Contains(string(body), test.body)) {
+					t.Errorf("Response incorrect. Expected %d %q Actual %d %q", test.status, test.body, res.StatusCode, body)
+				}
+			}
+			if test.err && err == nil {
+				t.Errorf("Expected the request to fail")
+			}
+			if !test.err && err != nil {
+				t.Errorf("Expected the request to succeed but got %v", err)
+			}
+		})
+	}
+}
+
+func TestFaultScripts(t *testing.T) {
+	log.Println("Testing scripted faults")
+
+	t.Setenv(RECORD_ENV, "")
+	server := NewServer(t, FIXTURES)
+
+	// fail twice, then answer null once, then recover
+	server.Inject(ROUTE_ITEM, Fault{Status: http.StatusInternalServerError, Times: 2})
+	server.Inject(ROUTE_ITEM, Fault{Null: true, Times: 1})
+
+	expected := []string{"500", "500", "null", "moks"}
+	for i, want := range expected {
+		status, body := helperGet(t, server.APIURL()+"/item/20324021.json")
+		if !strings.Contains(strconv.Itoa(status)+body, want) {
+			t.Errorf("Request %d incorrect. Expected %q Actual %d %q", i+1, want, status, body)
+		}
+	}
+
+	server.Inject(ROUTE_ANY, Fault{Status: http.StatusBadGateway})
+	server.ClearFaults()
+	if status, _ := helperGet(t, server.APIURL()+"/maxitem.json"); status != http.StatusOK {
+		t.Errorf("Expected cleared faults not to apply but got %d", status)
+	}
+}
+
+func TestFaultRate(t *testing.T) {
+	log.Println("Testing fault rates")
+
+	t.Setenv(RECORD_ENV, "")
+	failures := func(seed int64) []int {
+		server := NewServer(t, FIXTURES, WithSeed(seed))
+		server.Inject(ROUTE_ITEM, Fault{Status: http.StatusInternalServerError, Rate: 0.3})
+		failed := []int{}
+		for i := 0; i < 100; i++ {
+			if status, _ := helperGet(t, server.APIURL()+"/item/20324021.json"); status != http.StatusOK {
+				failed = append(failed, i)
+			}
+		}
+		return failed
+	}
+
+	first, second := failures(7), failures(7)
+	if len(first) < 15 || len(first) > 45 {
+		t.Errorf("Expected about 30 of 100 requests to fail but %d did", len(first))
+	}
+	if fmt.Sprint(first) != fmt.Sprint(second) {
+		t.Errorf("Expected the same seed to fail the same requests")
+	}
+}
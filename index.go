@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/alis93/hn-scraper/crawler"
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/alis93/hn-scraper/index"
+)
+
+const DEFAULT_INDEX = "hn.index"
+
+// Builds a search index from the items saved by crawl.
+func defineIndex(flags *flag.FlagSet) func(args []string) {
+	snapshot := flags.String("snapshot", "", "Directory written by crawl --out")
+	out := flags.String("out", DEFAULT_INDEX, "File to write the index to")
+	comments := flags.Bool("comments", false, "Also index comments")
+	logs := addLogFlags(flags)
+	return func(args []string) {
+		logs.setup()
+
+		if *snapshot == "" {
+			fatal("--snapshot is required")
+		}
+
+		builder := index.NewBuilder(*comments)
+		err := crawler.ReadSnapshot(*snapshot, func(item *hackernews.RawItem) error {
+			builder.Add(item)
+			return nil
+		})
+		if err != nil {
+			fatalErr(err)
+		}
+
+		idx := builder.Index()
+		if err := idx.Save(*out); err != nil {
+			fatalErr(err)
+		}
+		fmt.Fprintf(os.Stdout, "Indexed %d documents and %d terms into %s\n", len(idx.Docs), len(idx.Postings), *out)
+	}
+}
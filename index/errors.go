@@ -0,0 +1,27 @@
+package index
+
+import "fmt"
+
+var EmptyQueryErr = fmt.Errorf("Query has no searchable words")
+
+type VersionErr struct {
+	path    string
+	version int
+}
+
+func (e *VersionErr) Error() string {
+	return fmt.Sprintf("%s was built by a different version (%d, expected %d). Build the index again", e.path, e.version, INDEX_VERSION)
+}
+
+type CorruptIndexErr struct {
+	path string
+	err  error
+}
+
+func (e *CorruptIndexErr) Error() string {
+	return fmt.Sprintf("%s is not a valid index. \t %s", e.path, e.err.Error())
+}
+
+func (e *CorruptIndexErr) Unwrap() error {
+	return e.err
+}
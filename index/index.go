@@ -0,0 +1,345 @@
+package index
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+const (
+	// Changed whenever the saved format changes, so old index files are rebuilt rather than misread
+	INDEX_VERSION = 1
+	// Title terms count this many times, so matches in the title rank higher than matches in the text
+	TITLE_WEIGHT = 3
+	// BM25 term frequency saturation and length normalisation
+	BM25_K1 = 1.2
+	BM25_B  = 0.75
+	// Number of words either side of the best match shown in a snippet
+	SNIPPET_WORDS = 12
+	// Marks around matched words in highlights
+	HIGHLIGHT_START = "**"
+	HIGHLIGHT_END   = "**"
+)
+
+// A story or comment in the index
+type Document struct {
+	ID   int
+	Type string
+	// The story a comment belongs to, or the story itself. 0 if the story is not in the snapshot
+	StoryID int
+	Title   string
+	Text    string
+	URL     string
+	Author  string
+	Time    int
+	// Number of terms, counting title terms TITLE_WEIGHT times
+	Length int
+}
+
+// The documents a term appears in
+type Posting struct {
+	Doc   int
+	Count int
+}
+
+// An inverted index of stories and comments, searchable with BM25
+type Index struct {
+	Version  int
+	Docs     []Document
+	Postings map[string][]Posting
+	// Sum of the lengths of every document
+	TotalLength int
+}
+
+// A search result
+type Hit struct {
+	ID      int     `json:"id"`
+	Type    string  `json:"type"`
+	StoryID int     `json:"storyId,omitempty"`
+	Title   string  `json:"title,omitempty"`
+	URL     string  `json:"url,omitempty"`
+	Author  string  `json:"author"`
+	Score   float64 `json:"score"`
+	// Text around the best match, with matched words highlighted
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// Builds an index from items one at a time
+type Builder struct {
+	comments bool
+	index    *Index
+	// the story of every item added so far, so comments can find their story through their parent
+	storyOf map[int]int
+}
+
+// Creates a builder. Comments are only indexed if comments is set
+func NewBuilder(comments bool) *Builder {
+	return &Builder{
+		comments: comments,
+		index:    &Index{Version: INDEX_VERSION, Postings: make(map[string][]Posting)},
+		storyOf:  make(map[int]int),
+	}
+}
+
+// Adds an item to the index. Deleted and dead items are skipped.
+// Items must be added in id order so a comment's parent is always added before it
+func (b *Builder) Add(item *hackernews.RawItem) {
+	if item == nil || item.Deleted || item.Dead {
+		return
+	}
+
+	switch item.ItemType {
+	case "story", "job", "poll":
+		b.storyOf[item.ID] = item.ID
+	case "comment":
+		b.storyOf[item.ID] = b.storyOf[item.Parent]
+		if !b.comments {
+			return
+		}
+	default:
+		return
+	}
+
+	doc := Document{
+		ID:      item.ID,
+		Type:    item.ItemType,
+		StoryID: b.storyOf[item.ID],
+		Title:   item.Title,
+		Text:    hackernews.PlainText(item.Text),
+		URL:     item.URL,
+		Author:  item.By,
+		Time:    item.Timestamp,
+	}
+
+	counts := make(map[string]int)
+	for _, t := range Tokenize(doc.Title) {
+		counts[t] += TITLE_WEIGHT
+		doc.Length += TITLE_WEIGHT
+	}
+	for _, t := range Tokenize(doc.Text) {
+		counts[t]++
+		doc.Length++
+	}
+	if doc.Length == 0 {
+		return
+	}
+
+	position := len(b.index.Docs)
+	b.index.Docs = append(b.index.Docs, doc)
+	b.index.TotalLength += doc.Length
+	for t, count := range counts {
+		b.index.Postings[t] = append(b.index.Postings[t], Posting{position, count})
+	}
+}
+
+// Returns the index built so far
+func (b *Builder) Index() *Index {
+	return b.index
+}
+
+// Returns the best limit documents for the query, best first.
+// Documents are scored with BM25 and match if they contain any of the query terms.
+// Returns EmptyQueryErr if the query has no searchable words
+func (idx *Index) Search(query string, limit int) ([]Hit, error) {
+	terms := uniqueTerms(query)
+	if len(terms) == 0 {
+		return nil, EmptyQueryErr
+	}
+	if len(idx.Docs) == 0 {
+		return []Hit{}, nil
+	}
+
+	n := float64(len(idx.Docs))
+	avgLength := float64(idx.TotalLength) / n
+	scores := make(map[int]float64)
+	for _, t := range terms {
+		postings := idx.Postings[t]
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for _, p := range postings {
+			tf := float64(p.Count)
+			length := float64(idx.Docs[p.Doc].Length)
+			scores[p.Doc] += idf * tf * (BM25_K1 + 1) / (tf + BM25_K1*(1-BM25_B+BM25_B*length/avgLength))
+		}
+	}
+
+	ranked := make([]int, 0, len(scores))
+	for doc := range scores {
+		ranked = append(ranked, doc)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if scores[ranked[i]] != scores[ranked[j]] {
+			return scores[ranked[i]] > scores[ranked[j]]
+		}
+		return idx.Docs[ranked[i]].ID > idx.Docs[ranked[j]].ID // newer first
+	})
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	matches := make(map[string]bool)
+	for _, t := range terms {
+		matches[t] = true
+	}
+	hits := make([]Hit, len(ranked))
+	for i, position := range ranked {
+		doc := idx.Docs[position]
+		hits[i] = Hit{
+			ID:      doc.ID,
+			Type:    doc.Type,
+			StoryID: doc.StoryID,
+			Title:   highlight(doc.Title, matches),
+			URL:     doc.URL,
+			Author:  doc.Author,
+			Score:   scores[position],
+			Snippet: snippet(doc.Text, matches),
+		}
+	}
+	return hits, nil
+}
+
+// Returns the distinct terms of the query
+func uniqueTerms(query string) []string {
+	seen := make(map[string]bool)
+	terms := []string{}
+	for _, t := range Tokenize(query) {
+		if !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+// Wraps the words of text whose term is in matches with HIGHLIGHT_START and HIGHLIGHT_END
+func highlight(text string, matches map[string]bool) string {
+	return highlightWords(text, words(text), matches)
+}
+
+func highlightWords(text string, found []word, matches map[string]bool) string {
+	var out strings.Builder
+	last := 0
+	for _, w := range found {
+		if !matches[term(w.text)] {
+			continue
+		}
+		out.WriteString(text[last:w.start])
+		out.WriteString(HIGHLIGHT_START + w.text + HIGHLIGHT_END)
+		last = w.end
+	}
+	out.WriteString(text[last:])
+	return out.String()
+}
+
+// Returns up to SNIPPET_WORDS words either side of the part of text with the most matches, highlighted
+func snippet(text string, matches map[string]bool) string {
+	found := words(text)
+	if len(found) == 0 {
+		return ""
+	}
+
+	matched := make([]bool, len(found))
+	for i, w := range found {
+		matched[i] = matches[term(w.text)]
+	}
+
+	// centre the snippet on the match with the most other matches around it
+	centre, bestCount := 0, 0
+	for i := range found {
+		if !matched[i] {
+			continue
+		}
+		count := 0
+		for j := max(i-SNIPPET_WORDS, 0); j <= min(i+SNIPPET_WORDS, len(found)-1); j++ {
+			if matched[j] {
+				count++
+			}
+		}
+		if count > bestCount {
+			centre, bestCount = i, count
+		}
+	}
+	if bestCount == 0 {
+		centre = SNIPPET_WORDS
+	}
+
+	best := max(centre-SNIPPET_WORDS, 0)
+	end := min(centre+SNIPPET_WORDS+1, len(found))
+	window := found[best:end]
+
+	// keep punctuation before the first word and after the last when the snippet reaches the ends of the text
+	from, to := window[0].start, window[len(window)-1].end
+	if best == 0 {
+		from = 0
+	}
+	if end == len(found) {
+		to = len(text)
+	}
+	shifted := make([]word, len(window))
+	for i, w := range window {
+		shifted[i] = word{w.text, w.start - from, w.end - from}
+	}
+
+	text = text[from:to]
+	result := strings.Join(strings.Fields(highlightWords(text, shifted, matches)), " ")
+	if best > 0 {
+		result = "…" + result
+	}
+	if end < len(found) {
+		result += "…"
+	}
+	return result
+}
+
+// Writes the index to path, compressed.
+// Writes to a temporary file first and renames it so a crash never leaves a half written index
+func (idx *Index) Save(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	compressed := gzip.NewWriter(tmp)
+	if err := gob.NewEncoder(compressed).Encode(idx); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := compressed.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Reads an index written by Save.
+// Returns *VersionErr if it was written by an incompatible version
+func Load(path string) (*Index, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	compressed, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, &CorruptIndexErr{path, err}
+	}
+	idx := &Index{}
+	if err := gob.NewDecoder(compressed).Decode(idx); err != nil {
+		return nil, &CorruptIndexErr{path, err}
+	}
+	if idx.Version != INDEX_VERSION {
+		return nil, &VersionErr{path, idx.Version}
+	}
+	return idx, nil
+}
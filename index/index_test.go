@@ -0,0 +1,148 @@
+package index
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alis93/hn-scraper/crawler"
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/google/go-cmp/cmp"
+)
+
+// Builds an index from the snapshot in testdata
+func buildTestIndex(t *testing.T, comments bool) *Index {
+	builder := NewBuilder(comments)
+	err := crawler.ReadSnapshot("./testdata/snapshot", func(item *hackernews.RawItem) error {
+		builder.Add(item)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return builder.Index()
+}
+
+func hitIds(hits []Hit) []int {
+	ids := []int{}
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestSearch(t *testing.T) {
+	log.Println("Testing searching the index")
+
+	stories := buildTestIndex(t, false)
+	withComments := buildTestIndex(t, true)
+
+	if len(stories.Docs) != 4 || len(withComments.Docs) != 6 {
+		t.Errorf("Expected 4 stories and 6 documents with comments, but got %d and %d", len(stories.Docs), len(withComments.Docs))
+	}
+
+	searchTests := []struct {
+		name     string
+		index    *Index
+		query    string
+		expected []int
+	}{
+		{"title", stories, "programming", []int{4, 5}},
+		{"stemmed", stories, "program", []int{4, 5}},
+		{"text", stories, "tutorials", []int{5}},
+		{"job", stories, "distributed systems", []int{8}},
+		{"comments left out", stories, "startups", []int{}},
+		{"comments", withComments, "startup running", []int{2, 3}},
+		{"html entities", withComments, "dying", []int{2}},
+		{"no match", withComments, "kubernetes", []int{}},
+	}
+
+	for _, test := range searchTests {
+		t.Run(test.name, func(t *testing.T) {
+			hits, err := test.index.Search(test.query, 10)
+			if err != nil {
+				t.Fatalf("Search failed. Reason : %s", err.Error())
+			}
+			if ids := hitIds(hits); !cmp.Equal(ids, test.expected) {
+				t.Errorf("Hits incorrect. \n\t Expected %v Actual %v", test.expected, ids)
+			}
+		})
+	}
+
+	if _, err := stories.Search("the of and", 10); err != EmptyQueryErr {
+		t.Errorf("Expected EmptyQueryErr for a query of stop words but got %v", err)
+	}
+	if hits, _ := stories.Search("programming", 1); len(hits) != 1 {
+		t.Errorf("Expected limit to cut hits to 1 but got %d", len(hits))
+	}
+}
+
+func TestSearchHighlights(t *testing.T) {
+	idx := buildTestIndex(t, true)
+
+	hits, _ := idx.Search("programming", 10)
+	if hits[0].Title != "Teach Yourself **Programming** in Ten Years" {
+		t.Errorf("Title highlight incorrect. Actual %q", hits[0].Title)
+	}
+
+	hits, _ = idx.Search("money", 10)
+	if len(hits) != 1 || hits[0].StoryID != 1 {
+		t.Fatalf("Expected comment 3 of story 1 but got %+v", hits)
+	}
+	if expected := "Agreed. Most startups die from running out of **money**, not competition."; hits[0].Snippet != expected {
+		t.Errorf("Snippet incorrect. \n\t Expected %q \n\t Actual %q", expected, hits[0].Snippet)
+	}
+
+	long := "one two three four five six seven eight nine ten eleven twelve thirteen fourteen fifteen " +
+		"sixteen seventeen eighteen nineteen twenty needle twentyone twentytwo twentythree twentyfour twentyfive " +
+		"twentysix twentyseven twentyeight twentynine thirty thirtyone thirtytwo thirtythree thirtyfour"
+	expected := "…nine ten eleven twelve thirteen fourteen fifteen sixteen seventeen eighteen nineteen twenty **needle** " +
+		"twentyone twentytwo twentythree twentyfour twentyfive twentysix twentyseven twentyeight twentynine thirty thirtyone thirtytwo…"
+	if s := snippet(long, map[string]bool{"needl": true}); s != expected {
+		t.Errorf("Long snippet incorrect. \n\t Expected %q \n\t Actual %q", expected, s)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	log.Println("Testing saving and loading the index")
+
+	dir, err := ioutil.TempDir("", "index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	idx := buildTestIndex(t, true)
+	path := filepath.Join(dir, "hn.index")
+	if err := idx.Save(path); err != nil {
+		t.Fatalf("Failed to save index. Reason : %s", err.Error())
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load index. Reason : %s", err.Error())
+	}
+	if !cmp.Equal(loaded, idx) {
+		t.Errorf("Loaded index differs. \n\t %s", cmp.Diff(idx, loaded))
+	}
+
+	idx.Version = INDEX_VERSION + 1
+	idx.Save(path)
+	if _, err := Load(path); err == nil {
+		t.Errorf("Expected an index of another version to fail")
+	} else if _, ok := err.(*VersionErr); !ok {
+		t.Errorf("Expected *VersionErr but got %v", err)
+	}
+
+	ioutil.WriteFile(path, []byte("not an index"), 0644)
+	if _, ok := loadErr(path).(*CorruptIndexErr); !ok {
+		t.Errorf("Expected *CorruptIndexErr for an invalid file")
+	}
+}
+
+func loadErr(path string) error {
+	_, err := Load(path)
+	return err
+}
@@ -0,0 +1,248 @@
+package index
+
+// An implementation of the Porter stemming algorithm,
+// following Martin Porter's reference C implementation.
+// See https://tartarus.org/martin/PorterStemmer/
+
+// Returns the stem of a lowercase word, for example connect for connections.
+// Words with characters other than a to z, and words of two letters or fewer, are returned unchanged
+func Stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	s := &stemmer{b: []byte(word), k: len(word) - 1}
+	s.step1ab()
+	if s.k > 0 {
+		s.step1c()
+		s.step2()
+		s.step3()
+		s.step4()
+		s.step5()
+	}
+	return string(s.b[:s.k+1])
+}
+
+// b[0..k] is the word being stemmed. j marks the end of the stem before a matched suffix
+type stemmer struct {
+	b    []byte
+	k, j int
+}
+
+// Returns true if b[i] is a consonant
+func (s *stemmer) cons(i int) bool {
+	switch s.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !s.cons(i-1)
+	}
+	return true
+}
+
+// Returns the number of vowel consonant sequences in b[0..j]
+func (s *stemmer) m() int {
+	n, i := 0, 0
+	for ; i <= s.j && s.cons(i); i++ {
+	}
+	for {
+		for ; i <= s.j && !s.cons(i); i++ {
+		}
+		if i > s.j {
+			return n
+		}
+		for ; i <= s.j && s.cons(i); i++ {
+		}
+		n++
+		if i > s.j {
+			return n
+		}
+	}
+}
+
+// Returns true if b[0..j] has a vowel
+func (s *stemmer) vowelInStem() bool {
+	for i := 0; i <= s.j; i++ {
+		if !s.cons(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if b[i-1..i] is a double consonant
+func (s *stemmer) doubleCons(i int) bool {
+	return i >= 1 && s.b[i] == s.b[i-1] && s.cons(i)
+}
+
+// Returns true if b[i-2..i] is consonant vowel consonant and the last consonant is not w, x or y.
+// Used to restore an e at the end of short words, for example hop(e)
+func (s *stemmer) cvc(i int) bool {
+	if i < 2 || !s.cons(i) || s.cons(i-1) || !s.cons(i-2) {
+		return false
+	}
+	switch s.b[i] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
+
+// Returns true if b[0..k] ends with suffix, setting j to the end of the stem before it
+func (s *stemmer) ends(suffix string) bool {
+	n := len(suffix)
+	if n > s.k+1 || string(s.b[s.k-n+1:s.k+1]) != suffix {
+		return false
+	}
+	s.j = s.k - n
+	return true
+}
+
+// Replaces b[j+1..k] with suffix
+func (s *stemmer) setTo(suffix string) {
+	s.b = append(s.b[:s.j+1], suffix...)
+	s.k = s.j + len(suffix)
+}
+
+// Replaces the matched suffix if the stem has at least one vowel consonant sequence
+func (s *stemmer) replace(suffix string) {
+	if s.m() > 0 {
+		s.setTo(suffix)
+	}
+}
+
+// Removes plurals and -ed or -ing
+func (s *stemmer) step1ab() {
+	if s.b[s.k] == 's' {
+		switch {
+		case s.ends("sses"):
+			s.k -= 2
+		case s.ends("ies"):
+			s.setTo("i")
+		case s.b[s.k-1] != 's':
+			s.k--
+		}
+	}
+
+	if s.ends("eed") {
+		if s.m() > 0 {
+			s.k--
+		}
+	} else if (s.ends("ed") || s.ends("ing")) && s.vowelInStem() {
+		s.k = s.j
+		switch {
+		case s.ends("at"):
+			s.setTo("ate")
+		case s.ends("bl"):
+			s.setTo("ble")
+		case s.ends("iz"):
+			s.setTo("ize")
+		case s.doubleCons(s.k):
+			s.k--
+			switch s.b[s.k] {
+			case 'l', 's', 'z':
+				s.k++
+			}
+		case s.m() == 1 && s.cvc(s.k):
+			s.setTo("e")
+		}
+	}
+}
+
+// Turns a terminal y into i when there is another vowel in the stem
+func (s *stemmer) step1c() {
+	if s.ends("y") && s.vowelInStem() {
+		s.b[s.k] = 'i'
+	}
+}
+
+// Suffixes replaced by step 2, keyed by the second to last letter
+var step2Suffixes = map[byte][][2]string{
+	'a': {{"ational", "ate"}, {"tional", "tion"}},
+	'c': {{"enci", "ence"}, {"anci", "ance"}},
+	'e': {{"izer", "ize"}},
+	'l': {{"bli", "ble"}, {"alli", "al"}, {"entli", "ent"}, {"eli", "e"}, {"ousli", "ous"}},
+	'o': {{"ization", "ize"}, {"ation", "ate"}, {"ator", "ate"}},
+	's': {{"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"}, {"ousness", "ous"}},
+	't': {{"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"}},
+	'g': {{"logi", "log"}},
+}
+
+// Suffixes replaced by step 3, keyed by the last letter
+var step3Suffixes = map[byte][][2]string{
+	'e': {{"icate", "ic"}, {"ative", ""}, {"alize", "al"}},
+	'i': {{"iciti", "ic"}},
+	'l': {{"ical", "ic"}, {"ful", ""}},
+	's': {{"ness", ""}},
+}
+
+// Suffixes removed by step 4, keyed by the second to last letter
+var step4Suffixes = map[byte][]string{
+	'a': {"al"},
+	'c': {"ance", "ence"},
+	'e': {"er"},
+	'i': {"ic"},
+	'l': {"able", "ible"},
+	'n': {"ant", "ement", "ment", "ent"},
+	'o': {"ion", "ou"},
+	's': {"ism"},
+	't': {"ate", "iti"},
+	'u': {"ous"},
+	'v': {"ive"},
+	'z': {"ize"},
+}
+
+// Maps double suffixes to single ones, for example -ization to -ize
+func (s *stemmer) step2() {
+	for _, suffix := range step2Suffixes[s.b[s.k-1]] {
+		if s.ends(suffix[0]) {
+			s.replace(suffix[1])
+			return
+		}
+	}
+}
+
+// Handles -ic-, -full, -ness and the like
+func (s *stemmer) step3() {
+	for _, suffix := range step3Suffixes[s.b[s.k]] {
+		if s.ends(suffix[0]) {
+			s.replace(suffix[1])
+			return
+		}
+	}
+}
+
+// Removes -ant, -ence and the like from stems with more than one vowel consonant sequence
+func (s *stemmer) step4() {
+	for _, suffix := range step4Suffixes[s.b[s.k-1]] {
+		if !s.ends(suffix) {
+			continue
+		}
+		// -ion is only removed after s or t
+		if suffix == "ion" && (s.j < 0 || (s.b[s.j] != 's' && s.b[s.j] != 't')) {
+			continue
+		}
+		if s.m() > 1 {
+			s.k = s.j
+		}
+		return
+	}
+}
+
+// Removes a final -e and turns -ll into -l on longer stems
+func (s *stemmer) step5() {
+	s.j = s.k
+	if s.b[s.k] == 'e' {
+		if a := s.m(); a > 1 || (a == 1 && !s.cvc(s.k-1)) {
+			s.k--
+		}
+	}
+	if s.b[s.k] == 'l' && s.doubleCons(s.k) && s.m() > 1 {
+		s.k--
+	}
+}
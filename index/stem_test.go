@@ -0,0 +1,58 @@
+package index
+
+import (
+	"log"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	log.Println("Testing stemming")
+
+	// from the examples in Porter's paper
+	stemTests := []struct {
+		input    string
+		expected string
+	}{
+		{"caresses", "caress"},
+		{"ponies", "poni"},
+		{"ties", "ti"},
+		{"caress", "caress"},
+		{"cats", "cat"},
+		{"feed", "feed"},
+		{"agreed", "agre"},
+		{"plastered", "plaster"},
+		{"motoring", "motor"},
+		{"sing", "sing"},
+		{"conflated", "conflat"},
+		{"troubled", "troubl"},
+		{"sized", "size"},
+		{"hopping", "hop"},
+		{"falling", "fall"},
+		{"filing", "file"},
+		{"happy", "happi"},
+		{"relational", "relat"},
+		{"conditional", "condit"},
+		{"rational", "ration"},
+		{"generalization", "gener"},
+		{"hopeful", "hope"},
+		{"goodness", "good"},
+		{"adjustable", "adjust"},
+		{"adoption", "adopt"},
+		{"connections", "connect"},
+		{"connecting", "connect"},
+		{"programming", "program"},
+		{"programmers", "programm"},
+		{"controll", "control"},
+		{"probate", "probat"},
+		{"rate", "rate"},
+		{"go", "go"},
+		{"café", "café"},
+		{"h2o", "h2o"},
+	}
+
+	for _, test := range stemTests {
+		if stem := Stem(test.input); stem != test.expected {
+			t.Errorf("%s failed. \n\t Expected: %s Actual %s ", test.input, test.expected, stem)
+		}
+	}
+}
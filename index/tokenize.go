@@ -0,0 +1,61 @@
+package index
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Common words left out of the index, as they match almost every document
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "but": true,
+	"by": true, "for": true, "from": true, "has": true, "have": true, "i": true, "if": true, "in": true,
+	"into": true, "is": true, "it": true, "its": true, "of": true, "on": true, "or": true, "so": true,
+	"that": true, "the": true, "their": true, "then": true, "there": true, "these": true, "they": true,
+	"this": true, "to": true, "was": true, "were": true, "will": true, "with": true, "you": true,
+}
+
+// A word of some text and where it is
+type word struct {
+	text       string
+	start, end int
+}
+
+// Splits text into words of letters and numbers, keeping their byte offsets
+func words(text string) []word {
+	found := []word{}
+	start := -1
+	for i, r := range text {
+		isWordRune := unicode.IsLetter(r) || unicode.IsNumber(r)
+		if isWordRune && start < 0 {
+			start = i
+		}
+		if !isWordRune && start >= 0 {
+			found = append(found, word{text[start:i], start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		found = append(found, word{text[start:], start, len(text)})
+	}
+	return found
+}
+
+// Returns the index term for a word, or an empty string for stop words
+func term(w string) string {
+	w = strings.ToLower(w)
+	if stopWords[w] {
+		return ""
+	}
+	return Stem(w)
+}
+
+// Returns the index terms of text in order
+func Tokenize(text string) []string {
+	terms := []string{}
+	for _, w := range words(text) {
+		if t := term(w.text); t != "" {
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
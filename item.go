@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"strconv"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+// Prints items as JSON, as the source returns them. Works for comments and polls as well as stories.
+func defineItem(flags *flag.FlagSet) func(args []string) {
+	ndjson := flags.Bool("ndjson", false, "Print one item per line")
+	logs := addLogFlags(flags)
+	settings := addConfigFlags(flags)
+	sources := addSourceFlags(flags)
+	return func(args []string) {
+		logs.setup()
+		cfg := settings.load()
+
+		if len(args) == 0 {
+			fatal("an item id is required, for example hn-scraper item 20324021")
+		}
+		ids := make([]int, len(args))
+		for i, arg := range args {
+			id, err := strconv.Atoi(arg)
+			if err != nil || id <= 0 {
+				fatal("invalid item id", "value", arg)
+			}
+			ids[i] = id
+		}
+
+		source := sources.open(cfg)
+		encoder := json.NewEncoder(os.Stdout)
+		if !*ndjson {
+			encoder.SetIndent("", "    ")
+		}
+		failed := 0
+		for _, id := range ids {
+			item, err := source.Item(context.Background(), id)
+			if err != nil {
+				Logger.Error("unable to get item", "item_id", id, "error", err, "error_kind", hackernews.ErrorKind(err))
+				failed++
+				continue
+			}
+			encoder.Encode(item)
+		}
+		sources.close()
+
+		if failed > 0 {
+			fatal("items failed", "failed", failed, "total", len(ids))
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alis93/hn-scraper/config"
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/alis93/hn-scraper/linkcheck"
+)
+
+// Checks whether the urls of scraped stories still work.
+// Stories are scraped live or read from an NDJSON file written with --ndjson.
+func defineLinkcheck(flags *flag.FlagSet) func(args []string) {
+	input := flags.String("input", "", "NDJSON file of stories to check. Use - for stdin. Scrapes --list when empty")
+	list := flags.String("list", "top", "Story list to scrape. One of top, new, best, ask, show or job")
+	posts := flags.Int("posts", 30, "How many stories of the list to scrape")
+	workers := flags.Int("workers", 8, "How many urls to check at once")
+	perHost := flags.Int("per-host", 2, "How many requests to send to a single host at once")
+	hostDelay := flags.Duration("host-delay", 500*time.Millisecond, "Time to wait between requests to the same host")
+	maxRedirects := flags.Int("max-redirects", linkcheck.MAX_REDIRECTS, "How many redirects to follow")
+	format := flags.String("format", "text", "Output format. Either text or json, one result per line")
+	logs := addLogFlags(flags)
+	settings := addConfigFlags(flags)
+	sources := addSourceFlags(flags)
+	return func(args []string) {
+		logs.setup()
+		cfg := settings.load()
+
+		if *format != "text" && *format != "json" {
+			fatal("invalid --format, must be text or json", "value", *format)
+		}
+
+		stories := loadStories(cfg, sources, *input, *list, *posts)
+
+		checker, err := linkcheck.NewChecker(cfg.Client.Timeout, *workers, *perHost,
+			linkcheck.WithHostDelay(*hostDelay),
+			linkcheck.WithMaxRedirects(*maxRedirects),
+			linkcheck.WithLogger(Logger))
+		if err != nil {
+			fatalErr(err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		results := checker.CheckStories(ctx, stories)
+
+		if *format == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			for _, result := range results {
+				encoder.Encode(result)
+			}
+			return
+		}
+
+		dead := 0
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "STORY\tSTATUS\tLATENCY\tURL\tFINAL URL")
+		for _, result := range results {
+			status := fmt.Sprint(result.Status)
+			if result.Err != "" {
+				status = "error"
+			}
+			if !result.Alive() {
+				dead++
+			}
+			final := ""
+			if len(result.Redirects) > 0 {
+				final = fmt.Sprintf("%s (%d redirects)", result.FinalURL, len(result.Redirects))
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", result.StoryID, status, result.Latency.Round(time.Millisecond), result.URL, final)
+		}
+		w.Flush()
+
+		for _, result := range results {
+			if result.Err != "" {
+				fmt.Fprintf(os.Stderr, "%d: %s\n", result.StoryID, result.Err)
+			}
+		}
+		fmt.Fprintf(os.Stdout, "%d of %d links are dead\n", dead, len(results))
+	}
+}
+
+// Reads stories from the NDJSON file at path, or scrapes them from list if path is empty
+func loadStories(cfg *config.Config, sources *sourceFlags, path, list string, posts int) []*hackernews.Story {
+	if path == "" {
+		source := sources.open(cfg)
+		converter, err := newConverter(cfg.Converter)
+		if err != nil {
+			fatalErr(err)
+		}
+		stories, err := fetchStories(source, converter, list, posts, nil)
+		if err != nil {
+			fatalErr(err)
+		}
+		sources.close()
+		return stories
+	}
+
+	file := os.Stdin
+	if path != "-" {
+		var err error
+		if file, err = os.Open(path); err != nil {
+			fatalErr(err)
+		}
+		defer file.Close()
+	}
+	stories, err := hackernews.ReadStories(file)
+	if err != nil {
+		fatalErr(err)
+	}
+	return stories
+}
@@ -0,0 +1,18 @@
+package linkcheck
+
+import "fmt"
+
+var (
+	InvalidTimeOutErr = fmt.Errorf("Timeout must be more than 0 seconds")
+	InvalidWorkersErr = fmt.Errorf("Number of workers must be more than 0")
+	InvalidPerHostErr = fmt.Errorf("Requests per host must be more than 0")
+)
+
+type TooManyRedirectsErr struct {
+	url string
+	max int
+}
+
+func (e *TooManyRedirectsErr) Error() string {
+	return fmt.Sprintf("%s redirected more than %d times", e.url, e.max)
+}
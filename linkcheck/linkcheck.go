@@ -0,0 +1,287 @@
+package linkcheck
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+const (
+	MAX_REDIRECTS = 10
+	USER_AGENT    = "hn-scraper linkcheck (+https://github.com/alis93/hn-scraper)"
+)
+
+// The outcome of checking one url
+type Result struct {
+	StoryID int    `json:"storyId,omitempty"`
+	URL     string `json:"url"`
+	// Url of the last response, after following redirects
+	FinalURL string `json:"finalUrl,omitempty"`
+	// Status code of the last response, 0 if no response was received
+	Status int `json:"status"`
+	// Method of the last request, HEAD or GET
+	Method string `json:"method,omitempty"`
+	// Every url redirected to, in order
+	Redirects []string      `json:"redirects,omitempty"`
+	Latency   time.Duration `json:"latency"`
+	Err       string        `json:"error,omitempty"`
+}
+
+// Returns true if the url responded with 2xx
+func (r Result) Alive() bool {
+	return r.Err == "" && r.Status >= 200 && r.Status < 300
+}
+
+// Checks whether story urls still work.
+// Sends HEAD requests, falling back to GET for servers that do not handle HEAD, and follows redirects itself
+// so the chain can be reported. Limits how many requests each host receives at once.
+type Checker struct {
+	http         *http.Client
+	workers      int
+	perHost      int
+	hostDelay    time.Duration
+	maxRedirects int
+	userAgent    string
+	logger       *slog.Logger
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimit
+}
+
+// Requests in flight to, and the time of the last request to, a single host
+type hostLimit struct {
+	slots chan struct{}
+	mu    sync.Mutex
+	last  time.Time
+}
+
+// Optional settings applied when creating a checker
+type CheckerOption func(*Checker)
+
+// Waits at least delay between starting requests to the same host. By default there is no delay.
+func WithHostDelay(delay time.Duration) CheckerOption {
+	return func(c *Checker) {
+		c.hostDelay = delay
+	}
+}
+
+// Sets how many redirects are followed before giving up. Defaults to MAX_REDIRECTS.
+func WithMaxRedirects(max int) CheckerOption {
+	return func(c *Checker) {
+		c.maxRedirects = max
+	}
+}
+
+// Sets the User-Agent header sent with each request.
+func WithUserAgent(userAgent string) CheckerOption {
+	return func(c *Checker) {
+		c.userAgent = userAgent
+	}
+}
+
+// Logs each check at debug level to logger. By default nothing is logged.
+func WithLogger(logger *slog.Logger) CheckerOption {
+	return func(c *Checker) {
+		c.logger = logger
+	}
+}
+
+// Creates a checker with given timeout in seconds per request,
+// checking up to workers urls at once and sending at most perHost requests to a host at once
+func NewChecker(timeout, workers, perHost int, opts ...CheckerOption) (*Checker, error) {
+	if timeout <= 0 {
+		return nil, InvalidTimeOutErr
+	}
+	if workers <= 0 {
+		return nil, InvalidWorkersErr
+	}
+	if perHost <= 0 {
+		return nil, InvalidPerHostErr
+	}
+
+	checker := &Checker{
+		http: &http.Client{
+			Timeout: time.Duration(timeout) * time.Second,
+			// redirects are followed by check so each hop is recorded
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		workers:      workers,
+		perHost:      perHost,
+		maxRedirects: MAX_REDIRECTS,
+		userAgent:    USER_AGENT,
+		logger:       slog.New(slog.DiscardHandler),
+		hosts:        make(map[string]*hostLimit),
+	}
+	for _, opt := range opts {
+		opt(checker)
+	}
+	return checker, nil
+}
+
+// Checks the url of every story. Returns the results in the same order as stories, leaving out nil stories
+func (c *Checker) CheckStories(ctx context.Context, stories []*hackernews.Story) []Result {
+	stories = nonNil(stories)
+	results := make([]Result, len(stories))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < c.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = c.Check(ctx, stories[i].URL)
+				results[i].StoryID = stories[i].ID
+			}
+		}()
+	}
+
+	for i := range stories {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+func nonNil(stories []*hackernews.Story) []*hackernews.Story {
+	kept := make([]*hackernews.Story, 0, len(stories))
+	for _, story := range stories {
+		if story != nil {
+			kept = append(kept, story)
+		}
+	}
+	return kept
+}
+
+// Checks a single url, following up to the checker's maximum redirects
+func (c *Checker) Check(ctx context.Context, rawURL string) Result {
+	result := Result{URL: rawURL}
+	start := time.Now()
+	defer func() {
+		result.Latency = time.Since(start)
+		c.logger.Debug("checked link", "url", rawURL, "status", result.Status, "final_url", result.FinalURL,
+			"redirects", len(result.Redirects), "duration", result.Latency, "error", result.Err)
+	}()
+
+	current := rawURL
+	for {
+		res, method, err := c.request(ctx, current)
+		if err != nil {
+			result.Err = err.Error()
+			return result
+		}
+		result.FinalURL = current
+		result.Status = res.StatusCode
+		result.Method = method
+
+		location := res.Header.Get("Location")
+		if !isRedirect(res.StatusCode) || location == "" {
+			return result
+		}
+		if len(result.Redirects) >= c.maxRedirects {
+			result.Err = (&TooManyRedirectsErr{rawURL, c.maxRedirects}).Error()
+			return result
+		}
+
+		next, err := res.Request.URL.Parse(location)
+		if err != nil {
+			result.Err = err.Error()
+			return result
+		}
+		current = next.String()
+		result.Redirects = append(result.Redirects, current)
+	}
+}
+
+// Sends a HEAD request for rawURL, retrying with GET if HEAD fails or is refused.
+// Returns the response, whose body is already closed, and the method that produced it
+func (c *Checker) request(ctx context.Context, rawURL string) (*http.Response, string, error) {
+	res, err := c.send(ctx, http.MethodHead, rawURL)
+	if err == nil && res.StatusCode < 400 {
+		return res, http.MethodHead, nil
+	}
+	if ctx.Err() != nil {
+		return nil, "", ctx.Err()
+	}
+
+	// many servers reject or mishandle HEAD, so only trust a GET
+	res, err = c.send(ctx, http.MethodGet, rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+	return res, http.MethodGet, nil
+}
+
+// Sends a single request once the host has a free slot
+func (c *Checker) send(ctx context.Context, method, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	release, err := c.acquire(ctx, req.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	// only the status and headers are needed
+	res.Body.Close()
+	return res, nil
+}
+
+// Waits for a free slot for the url's host and for the host delay to pass since the last request.
+// Returns a function to release the slot
+func (c *Checker) acquire(ctx context.Context, u *url.URL) (func(), error) {
+	c.mu.Lock()
+	limit, ok := c.hosts[u.Host]
+	if !ok {
+		limit = &hostLimit{slots: make(chan struct{}, c.perHost)}
+		c.hosts[u.Host] = limit
+	}
+	c.mu.Unlock()
+
+	select {
+	case limit.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := func() { <-limit.slots }
+
+	limit.mu.Lock()
+	wait := c.hostDelay - time.Since(limit.last)
+	limit.last = time.Now().Add(max(wait, 0))
+	limit.mu.Unlock()
+
+	if wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+func isRedirect(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package linkcheck
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved-again", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/moved-again", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestCheck(t *testing.T) {
+	log.Println("Testing link checking")
+
+	server := newTestServer()
+	defer server.Close()
+
+	checker, err := NewChecker(5, 2, 2, WithMaxRedirects(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkTests := []struct {
+		path      string
+		status    int
+		method    string
+		final     string
+		redirects []string
+		alive     bool
+	}{
+		{"/ok", 200, "HEAD", "/ok", nil, true},
+		{"/no-head", 200, "GET", "/no-head", nil, true},
+		{"/moved", 200, "HEAD", "/ok", []string{"/moved-again", "/ok"}, true},
+		{"/gone", 410, "GET", "/gone", nil, false},
+		{"/loop", 302, "HEAD", "/loop", []string{"/loop", "/loop", "/loop"}, false},
+	}
+
+	for _, test := range checkTests {
+		t.Run(test.path, func(t *testing.T) {
+			result := checker.Check(context.Background(), server.URL+test.path)
+
+			redirects := []string(nil)
+			for _, redirect := range test.redirects {
+				redirects = append(redirects, server.URL+redirect)
+			}
+			if result.Status != test.status || result.Method != test.method || result.FinalURL != server.URL+test.final {
+				t.Errorf("Result incorrect. \n\t Expected %d %s %s Actual %d %s %s",
+					test.status, test.method, server.URL+test.final, result.Status, result.Method, result.FinalURL)
+			}
+			if !cmp.Equal(result.Redirects, redirects) {
+				t.Errorf("Redirects incorrect. \n\t Expected %v Actual %v", redirects, result.Redirects)
+			}
+			if result.Alive() != test.alive {
+				t.Errorf("Alive should be %t. Error %q", test.alive, result.Err)
+			}
+		})
+	}
+}
+
+func TestCheckUnreachable(t *testing.T) {
+	checker, _ := NewChecker(1, 1, 1)
+	result := checker.Check(context.Background(), "http://127.0.0.1:1/")
+	if result.Err == "" || result.Status != 0 || result.Alive() {
+		t.Errorf("Expected unreachable url to fail but got %+v", result)
+	}
+}
+
+func TestCheckStoriesPerHostLimit(t *testing.T) {
+	log.Println("Testing link checks respect the per host limit")
+
+	var inFlight, most int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(&most)
+			if current <= seen || atomic.CompareAndSwapInt32(&most, seen, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	stories := []*hackernews.Story{}
+	for i := 1; i <= 8; i++ {
+		stories = append(stories, &hackernews.Story{ID: i, URL: fmt.Sprintf("%s/%d", server.URL, i)})
+	}
+
+	checker, _ := NewChecker(5, 8, 2)
+	results := checker.CheckStories(context.Background(), stories)
+
+	for i, result := range results {
+		if result.StoryID != stories[i].ID || !result.Alive() {
+			t.Errorf("Result %d incorrect. %+v", i, result)
+		}
+	}
+	if most > 2 {
+		t.Errorf("Expected at most 2 requests to the host at once but got %d", most)
+	}
+}
+
+func TestCheckStoriesSkipsNil(t *testing.T) {
+	log.Println("Testing link checks skip nil stories")
+	server := newTestServer()
+	defer server.Close()
+
+	checker, _ := NewChecker(5, 2, 2)
+	stories := []*hackernews.Story{{ID: 1, URL: server.URL + "/ok"}, nil, {ID: 2, URL: server.URL + "/ok"}}
+	results := checker.CheckStories(context.Background(), stories)
+
+	if len(results) != 2 || results[0].StoryID != 1 || results[1].StoryID != 2 {
+		t.Errorf("Expected results for stories 1 and 2 but got %+v", results)
+	}
+}
+
+func TestHostDelay(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	checker, _ := NewChecker(5, 3, 3, WithHostDelay(30*time.Millisecond))
+	stories := []*hackernews.Story{{URL: server.URL + "/ok"}, {URL: server.URL + "/ok"}, {URL: server.URL + "/ok"}}
+
+	start := time.Now()
+	checker.CheckStories(context.Background(), stories)
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("Expected requests to the same host to be spaced out, but took %s", elapsed)
+	}
+}
+
+func TestNewCheckerErrors(t *testing.T) {
+	if _, err := NewChecker(0, 1, 1); err != InvalidTimeOutErr {
+		t.Errorf("Expected InvalidTimeOutErr but got %v", err)
+	}
+	if _, err := NewChecker(1, 0, 1); err != InvalidWorkersErr {
+		t.Errorf("Expected InvalidWorkersErr but got %v", err)
+	}
+	if _, err := NewChecker(1, 1, 0); err != InvalidPerHostErr {
+		t.Errorf("Expected InvalidPerHostErr but got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"os"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+// Logger used by every command. Configured by the --log-level and --log-format flags
+var Logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+// The logging flags of a command
+type logFlags struct {
+	level  *string
+	format *string
+}
+
+// Adds --log-level and --log-format to flags
+func addLogFlags(flags *flag.FlagSet) *logFlags {
+	return &logFlags{
+		level:  flags.String("log-level", "info", "Minimum level to log. One of debug, info, warn or error"),
+		format: flags.String("log-format", "text", "Format of log lines. Either text or json"),
+	}
+}
+
+// Replaces Logger using the parsed flags.
+// Exits if either flag is invalid
+func (l *logFlags) setup() {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*l.level)); err != nil {
+		fatal("invalid --log-level", "value", *l.level)
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	switch *l.format {
+	case "text":
+		Logger = slog.New(slog.NewTextHandler(os.Stderr, opts))
+	case "json":
+		Logger = slog.New(slog.NewJSONHandler(os.Stderr, opts))
+	default:
+		fatal("invalid --log-format, must be text or json", "value", *l.format)
+	}
+}
+
+// Logs msg as an error and exits
+func fatal(msg string, args ...interface{}) {
+	Logger.Error(msg, args...)
+	os.Exit(1)
+}
+
+// Logs err as an error and exits
+func fatalErr(err error) {
+	fatal(err.Error(), "error_kind", hackernews.ErrorKind(err))
+}
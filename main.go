@@ -3,79 +3,135 @@ package main
 import (
 	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
-	"sync"
-
-	"github.com/alis93/hn-scraper/hackernews"
+	"sort"
+	"strings"
 )
 
-var ErrorLog = log.New(os.Stderr,
-	"Error: ",
-	log.Ldate|log.Ltime|log.Lshortfile)
+const PROGRAM = "hn-scraper"
 
-func main() {
+// A subcommand, selected by the first argument
+type command struct {
+	// Arguments after the flags, shown in the help
+	args    string
+	summary string
+	// Adds the flags of the command and returns the function that runs it with the arguments left after the flags
+	define func(flags *flag.FlagSet) func(args []string)
+}
 
-	numPosts := getNumPostsArg()
-	fmt.Fprintf(os.Stdout, "Retrieving %d posts\n", numPosts)
+// Subcommands selected by the first argument.
+// Without a subcommand the top stories are printed.
+var commands = map[string]command{
+	"comments":  {"<id>", "Print the comment tree of a story", defineComments},
+	"crawl":     {"", "Crawl a range of item ids into sharded NDJSON files", defineCrawl},
+	"digest":    {"", "Email the top stories of a list", defineDigest},
+	"domains":   {"", "Report which domains the stories of a list come from", defineDomains},
+	"index":     {"", "Build a search index from the items saved by crawl", defineIndex},
+	"item":      {"<id>...", "Print items as JSON", defineItem},
+	"linkcheck": {"", "Check whether the urls of stories still work", defineLinkcheck},
+	"post":      {"", "Post the top stories of a list to a chat webhook", definePost},
+	"search":    {"<query>", "Search an index built by the index command", defineSearch},
+	"serve":     {"", "Serve stories as a JSON api", defineServe},
+	"snapshot":  {"", "Save story lists and their items to a directory", defineSnapshot},
+	"top":       {"", "Print the stories of a list", defineTop},
+	"user":      {"<username>", "Print a user's profile", defineUser},
+	"watch":     {"", "Post alerts when stories match rules", defineWatch},
+}
 
-	// create hackernews client
-	timeout := 5
-	client, err := hackernews.NewClient(timeout)
-	if err != nil {
-		ErrorLog.Fatal(err)
+// Commands handled by main itself, as they describe the other commands
+var builtins = map[string]string{
+	"completion": "Print a completion script for bash, zsh or fish",
+	"help":       "Show the flags of a command",
+}
+
+func main() {
+	name, args := "top", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	} else if len(args) > 0 && isHelpFlag(args[0]) {
+		name, args = "help", nil
 	}
 
-	// get array of top story ids
-	storyIds, err := client.GetTopStoryIds(numPosts)
-	if err != nil {
-		ErrorLog.Fatal(err)
+	switch name {
+	case "help":
+		runHelp(args)
+		return
+	case "completion":
+		runCompletion(args)
+		return
 	}
-	converter, err := hackernews.NewItemConverter(false, true, 256, 1, 1)
-	if err != nil {
-		ErrorLog.Fatal(err)
+
+	cmd, ok := commands[name]
+	if !ok {
+		usage(os.Stderr)
+		fatal("unknown command", "command", name)
 	}
+	flags := newFlagSet(name, cmd)
+	run := cmd.define(flags)
+	flags.Parse(args)
+	run(flags.Args())
+}
 
-	// Create a channel
-	storyChan := make(chan *hackernews.Story)
-	var wg sync.WaitGroup
-	// loop each id, retrieve item and convert to story then send to channel
-	for index, storyId := range storyIds {
-		wg.Add(1)
-		go func(index, storyId int) {
-			rawItem, err := client.GetItem(storyId)
-			if err != nil {
-				ErrorLog.Printf("Unable to get item with id %d, Reason: %s \n", storyId, err.Error())
-			}
+// Creates the flag set of a command, printing its help on -h
+func newFlagSet(name string, cmd command) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		w := flags.Output()
+		fmt.Fprintf(w, "Usage: %s %s [flags] %s\n\n%s\n\nFlags:\n", PROGRAM, name, cmd.args, cmd.summary)
+		flags.PrintDefaults()
+	}
+	return flags
+}
 
-			story, err := converter.Convert(index+1, rawItem)
-			if err != nil {
-				ErrorLog.Printf("Unable to convert item with id %d to story, Reason: %s \n", storyId, err.Error())
-			}
-			storyChan <- story
-			wg.Done()
-		}(index, storyId)
+// Returns the names of every command, including help and completion, sorted
+func commandNames() []string {
+	names := make([]string, 0, len(commands)+len(builtins))
+	for name := range commands {
+		names = append(names, name)
+	}
+	for name := range builtins {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
+}
 
-	go func() {
-		wg.Wait()
-		close(storyChan)
-	}()
+// Returns the one line description of a command
+func commandSummary(name string) string {
+	if summary, ok := builtins[name]; ok {
+		return summary
+	}
+	return commands[name].summary
+}
 
-	// listen and wait on channel
-	for story := range storyChan {
-		// print each story received on channel
-		fmt.Println(story)
+// Writes the list of commands
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags] [arguments]\n\nCommands:\n", PROGRAM)
+	for _, name := range commandNames() {
+		fmt.Fprintf(w, "  %-11s %s\n", name, commandSummary(name))
 	}
+	fmt.Fprintf(w, "\nWithout a command the top stories are printed, as with top.\n")
+	fmt.Fprintf(w, "Flags go before arguments. Run %s help <command> for the flags of a command.\n", PROGRAM)
 }
 
-//
-func getNumPostsArg() int {
-	numPosts := flag.Int("posts", 0, "How many posts to retrieve")
-	flag.Parse()
-	if *numPosts <= 0 || *numPosts > 100 {
-		ErrorLog.Printf("You requested %d posts. Must be between 0 and 100", *numPosts)
-		os.Exit(1)
+// Prints the list of commands, or the flags of the command named in args
+func runHelp(args []string) {
+	if len(args) == 0 {
+		usage(os.Stdout)
+		return
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		usage(os.Stderr)
+		fatal("unknown command", "command", args[0])
 	}
-	return *numPosts
+	flags := newFlagSet(args[0], cmd)
+	flags.SetOutput(os.Stdout)
+	cmd.define(flags)
+	flags.Usage()
+}
+
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
 }
@@ -0,0 +1,261 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Default histogram buckets for request latencies in seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Holds every metric and writes them in the Prometheus text format.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+	names   map[string]bool
+}
+
+// A metric family that can write itself in the text format
+type metric interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// A counter partitioned by label values
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// A histogram partitioned by label values
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64 // one per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// Name, help and label names shared by every metric type
+type desc struct {
+	metricName string
+	help       string
+	labels     []string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// Registers a new counter.
+// Panics if a metric with the same name is already registered, as that is a programming error
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		desc:   desc{name, help, labels},
+		values: make(map[string]float64),
+	}
+	r.register(c)
+	return c
+}
+
+// Registers a new histogram with the given upper bounds, which must be sorted.
+// Panics if a metric with the same name is already registered, as that is a programming error
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		desc:    desc{name, help, labels},
+		buckets: buckets,
+		series:  make(map[string]*histogram),
+	}
+	r.register(h)
+	return h
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[m.name()] {
+		panic(fmt.Sprintf("metric %s is already registered", m.name()))
+	}
+	r.names[m.name()] = true
+	r.metrics = append(r.metrics, m)
+}
+
+// Writes every metric in the Prometheus text exposition format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	metrics := make([]metric, len(r.metrics))
+	copy(metrics, r.metrics)
+	r.mu.Unlock()
+
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].name() < metrics[j].name() })
+
+	buf := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(buf)
+	}
+	return buf.Flush()
+}
+
+// Returns a handler serving the metrics, for mounting on /metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		r.WriteText(w)
+	})
+}
+
+// Increments the counter for the given label values by 1
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Adds v to the counter for the given label values
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	key := c.key(labelValues)
+	c.mu.Lock()
+	c.values[key] += v
+	c.mu.Unlock()
+}
+
+// Returns the current value of the counter for the given label values
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	key := c.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[key]
+}
+
+// Records a single observation for the given label values
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	series, ok := h.series[key]
+	if !ok {
+		series = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = series
+	}
+	for i, bound := range h.buckets {
+		if v <= bound {
+			series.counts[i]++
+			break
+		}
+	}
+	series.count++
+	series.sum += v
+}
+
+// Returns how many observations were recorded for the given label values
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if series, ok := h.series[key]; ok {
+		return series.count
+	}
+	return 0
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.header(w, "counter")
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.labelString(key, ""), formatFloat(c.values[key]))
+	}
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.header(w, "histogram")
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		series := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += series.counts[i]
+			le := fmt.Sprintf(`le="%s"`, formatFloat(bound))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelString(key, le), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelString(key, `le="+Inf"`), series.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, h.labelString(key, ""), formatFloat(series.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, h.labelString(key, ""), series.count)
+	}
+}
+
+func (d desc) name() string {
+	return d.metricName
+}
+
+func (d desc) header(w *bufio.Writer, metricType string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.metricName, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.metricName, metricType)
+}
+
+// Joins label values into a map key.
+// Panics if the wrong number of values is given, as that is a programming error
+func (d desc) key(labelValues []string) string {
+	if len(labelValues) != len(d.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values but got %d", d.metricName, len(d.labels), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+// Formats the label values in key as {name="value",...}, with extra appended at the end
+func (d desc) labelString(key string, extra string) string {
+	pairs := []string{}
+	if len(d.labels) > 0 {
+		for i, value := range strings.Split(key, "\xff") {
+			pairs = append(pairs, fmt.Sprintf(`%s="%s"`, d.labels[i], escapeLabel(value)))
+		}
+	}
+	if extra != "" {
+		pairs = append(pairs, extra)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"bytes"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCounterVec(t *testing.T) {
+	log.Println("Testing counters")
+	registry := NewRegistry()
+	requests := registry.NewCounterVec("hn_requests_total", "Requests sent.", "endpoint", "status")
+
+	requests.Inc("item", "200")
+	requests.Inc("item", "200")
+	requests.Add(3, "topstories", "500")
+
+	if v := requests.Value("item", "200"); v != 2 {
+		t.Errorf("Counter value incorrect. \n\t Expected %d Actual %v", 2, v)
+	}
+
+	out := &bytes.Buffer{}
+	if err := registry.WriteText(out); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# HELP hn_requests_total Requests sent.
+# TYPE hn_requests_total counter
+hn_requests_total{endpoint="item",status="200"} 2
+hn_requests_total{endpoint="topstories",status="500"} 3
+`
+	if out.String() != expected {
+		t.Errorf("Text output incorrect. \n\t Expected \n%s\n Actual \n%s", expected, out.String())
+	}
+}
+
+func TestHistogramVec(t *testing.T) {
+	log.Println("Testing histograms")
+	registry := NewRegistry()
+	latency := registry.NewHistogramVec("hn_latency_seconds", "Latency.", []float64{0.1, 1}, "endpoint")
+
+	latency.Observe(0.05, "item")
+	latency.Observe(0.5, "item")
+	latency.Observe(3, "item")
+
+	if count := latency.Count("item"); count != 3 {
+		t.Errorf("Histogram count incorrect. \n\t Expected %d Actual %d", 3, count)
+	}
+
+	out := &bytes.Buffer{}
+	registry.WriteText(out)
+	expected := `# HELP hn_latency_seconds Latency.
+# TYPE hn_latency_seconds histogram
+hn_latency_seconds_bucket{endpoint="item",le="0.1"} 1
+hn_latency_seconds_bucket{endpoint="item",le="1"} 2
+hn_latency_seconds_bucket{endpoint="item",le="+Inf"} 3
+hn_latency_seconds_sum{endpoint="item"} 3.55
+hn_latency_seconds_count{endpoint="item"} 3
+`
+	if out.String() != expected {
+		t.Errorf("Text output incorrect. \n\t Expected \n%s\n Actual \n%s", expected, out.String())
+	}
+}
+
+func TestLabelEscaping(t *testing.T) {
+	log.Println("Testing label values are escaped")
+	registry := NewRegistry()
+	errors := registry.NewCounterVec("errors_total", "Errors.", "reason")
+	errors.Inc("bad \"quote\"\nand \\ slash")
+
+	out := &bytes.Buffer{}
+	registry.WriteText(out)
+	if !strings.Contains(out.String(), `errors_total{reason="bad \"quote\"\nand \\ slash"} 1`) {
+		t.Errorf("Label was not escaped. Actual \n%s", out.String())
+	}
+}
+
+func TestRegistryHandler(t *testing.T) {
+	log.Println("Testing metrics handler")
+	registry := NewRegistry()
+	registry.NewCounterVec("b_total", "B.").Inc()
+	registry.NewCounterVec("a_total", "A.").Inc()
+
+	rec := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := rec.Body.String()
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Content type incorrect. Actual %s", rec.Header().Get("Content-Type"))
+	}
+	if strings.Index(body, "a_total 1") > strings.Index(body, "b_total 1") || !strings.Contains(body, "a_total 1") {
+		t.Errorf("Metrics should be sorted by name. Actual \n%s", body)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected registering a duplicate name to panic")
+		}
+	}()
+	registry.NewCounterVec("a_total", "A again.")
+}
@@ -0,0 +1,84 @@
+package offline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+const (
+	LIST_FILE = "%sstories.json"
+	ITEM_FILE = "item_%d.json"
+)
+
+// Reads lists and items from a directory of api responses laid out like hackernews/testdata,
+// with one file per list such as topstories.json and one per item such as item_20324021.json
+type DirSource struct {
+	dir string
+}
+
+var _ hackernews.Source = &DirSource{}
+
+// Creates a source reading from dir. Returns error if dir is not a directory
+func NewDirSource(dir string) (*DirSource, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return &DirSource{dir}, nil
+}
+
+// Returns the first n ids of the list's file.
+// Returns *MissingListErr if there is no file for the list
+func (d *DirSource) ListIDs(ctx context.Context, list string, n int) ([]int, error) {
+	if err := hackernews.ValidateList(list, n); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(d.dir, fmt.Sprintf(LIST_FILE, list))
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, &MissingListErr{list, path}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []int{}
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return nil, err
+	}
+	if n < len(ids) {
+		ids = ids[:n]
+	}
+	return ids, nil
+}
+
+// Returns the item in the item's file.
+// Returns *hackernews.MissingItemErr if there is no file for the item or it holds null, as the api returns for unknown ids
+func (d *DirSource) Item(ctx context.Context, id int) (*hackernews.RawItem, error) {
+	data, err := ioutil.ReadFile(filepath.Join(d.dir, fmt.Sprintf(ITEM_FILE, id)))
+	if os.IsNotExist(err) {
+		return nil, hackernews.NewMissingItemErr(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var item *hackernews.RawItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, hackernews.NewMissingItemErr(id)
+	}
+	return item, nil
+}
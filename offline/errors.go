@@ -0,0 +1,21 @@
+package offline
+
+import "fmt"
+
+type MissingListErr struct {
+	list string
+	path string
+}
+
+type NotRecordedErr struct {
+	method string
+	url    string
+}
+
+func (e *MissingListErr) Error() string {
+	return fmt.Sprintf("The %s list was not saved. %s does not exist", e.list, e.path)
+}
+
+func (e *NotRecordedErr) Error() string {
+	return fmt.Sprintf("No response was recorded for %s %s", e.method, e.url)
+}
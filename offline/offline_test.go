@@ -0,0 +1,207 @@
+package offline
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/google/go-cmp/cmp"
+)
+
+// The api fixtures of the hackernews package
+const FIXTURES = "../hackernews/testdata"
+
+func TestDirSource(t *testing.T) {
+	log.Println("Testing reading a fixtures directory")
+
+	source, err := NewDirSource(FIXTURES)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	ids, err := source.ListIDs(ctx, "top", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(ids, []int{20325395, 20328871, 20325925}) {
+		t.Errorf("Ids incorrect. %v", ids)
+	}
+
+	item, err := source.Item(ctx, 20324021)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.By != "moks" || item.Score != 110 {
+		t.Errorf("Item incorrect. %+v", item)
+	}
+
+	if _, err := source.ListIDs(ctx, "new", 3); err == nil {
+		t.Errorf("Expected missing list to fail")
+	} else if _, ok := err.(*MissingListErr); !ok {
+		t.Errorf("Expected *MissingListErr but got %v", err)
+	}
+	if _, err := source.Item(ctx, 1); err == nil {
+		t.Errorf("Expected missing item to fail")
+	} else if _, ok := err.(*hackernews.MissingItemErr); !ok {
+		t.Errorf("Expected *hackernews.MissingItemErr but got %v", err)
+	}
+
+	if _, err := NewDirSource(filepath.Join(FIXTURES, "topstories.json")); err == nil {
+		t.Errorf("Expected a file to be rejected")
+	}
+}
+
+func TestDirSourceNullItem(t *testing.T) {
+	dir := t.TempDir()
+	ioutil.WriteFile(filepath.Join(dir, "item_5.json"), []byte("null"), 0644)
+
+	source, _ := NewDirSource(dir)
+	if _, err := source.Item(context.Background(), 5); err == nil {
+		t.Errorf("Expected null item to fail")
+	} else if _, ok := err.(*hackernews.MissingItemErr); !ok {
+		t.Errorf("Expected *hackernews.MissingItemErr but got %v", err)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	log.Println("Testing saving and loading snapshots")
+
+	fixtures, _ := NewDirSource(FIXTURES)
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	saved, err := SaveSnapshot(ctx, fixtures, dir, []string{"top"}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := LoadSnapshot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !snapshot.Time.Equal(saved.Time) || !cmp.Equal(snapshot.Lists, saved.Lists) {
+		t.Errorf("Loaded snapshot differs. \n%s", cmp.Diff(saved.Lists, snapshot.Lists))
+	}
+
+	ids, err := snapshot.ListIDs(ctx, "top", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(ids, []int{20325395, 20328871}) {
+		t.Errorf("Ids incorrect. %v", ids)
+	}
+	for _, id := range saved.Lists["top"] {
+		expected, _ := fixtures.Item(ctx, id)
+		actual, err := snapshot.Item(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(expected, actual) {
+			t.Errorf("Item %d differs. \n%s", id, cmp.Diff(expected, actual))
+		}
+	}
+
+	if _, err := snapshot.ListIDs(ctx, "ask", 2); err == nil {
+		t.Errorf("Expected unsaved list to fail")
+	}
+	if _, err := snapshot.Item(ctx, 1); err == nil {
+		t.Errorf("Expected unsaved item to fail")
+	}
+
+	// a snapshot is never saved with items missing
+	if _, err := SaveSnapshot(ctx, &missingItems{fixtures}, t.TempDir(), []string{"top"}, 5); err == nil {
+		t.Errorf("Expected snapshot with a missing item to fail")
+	}
+}
+
+// A source that fails to return one item
+type missingItems struct {
+	hackernews.Source
+}
+
+func (m *missingItems) Item(ctx context.Context, id int) (*hackernews.RawItem, error) {
+	if id == 20325925 {
+		return nil, hackernews.NewMissingItemErr(id)
+	}
+	return m.Source.Item(ctx, id)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	log.Println("Testing recording and replaying responses")
+
+	var requests int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch r.URL.Path {
+		case "/v0/topstories.json":
+			w.Write([]byte("[20324021]"))
+		case "/v0/item/20324021.json":
+			data, _ := ioutil.ReadFile(filepath.Join(FIXTURES, "item_20324021.json"))
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(data)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer api.Close()
+
+	archive := NewArchive()
+	client, err := hackernews.NewClient(5, hackernews.WithAPIURL(api.URL+"/v0"), hackernews.WithTransport(archive.Recorder(nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	ids, err := client.ListIDs(ctx, "top", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := client.Item(ctx, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Item(ctx, 404)
+
+	path := filepath.Join(t.TempDir(), "archive.json")
+	if err := archive.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	api.Close()
+
+	loaded, err := LoadArchive(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Len() != 3 {
+		t.Errorf("Expected 3 recorded responses but got %d", loaded.Len())
+	}
+
+	// the server is closed, so every response comes from the archive
+	client, _ = hackernews.NewClient(5, hackernews.WithAPIURL(api.URL+"/v0"), hackernews.WithTransport(loaded.Replayer()))
+	replayed, err := client.Item(ctx, 20324021)
+	if err != nil {
+		t.Fatalf("Failed to replay item. Reason : %s", err.Error())
+	}
+	if !cmp.Equal(recorded, replayed) {
+		t.Errorf("Replayed item differs. \n%s", cmp.Diff(recorded, replayed))
+	}
+	if _, err := client.Item(ctx, 404); err == nil {
+		t.Errorf("Expected recorded 404 to fail")
+	} else if _, ok := err.(*hackernews.StatusCodeErr); !ok {
+		t.Errorf("Expected *hackernews.StatusCodeErr but got %v", err)
+	}
+	var notRecorded *NotRecordedErr
+	if _, err := client.Item(ctx, 7); !errors.As(err, &notRecorded) {
+		t.Errorf("Expected *NotRecordedErr but got %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("Expected 3 requests to the server but got %d", requests)
+	}
+}
@@ -0,0 +1,138 @@
+package offline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Responses recorded from live traffic, keyed by method and url, that can be replayed
+// so a run sends exactly the same responses through the pipeline again
+type Archive struct {
+	mu      sync.Mutex
+	entries map[string]*Entry
+}
+
+// A recorded response
+type Entry struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// Creates an empty archive
+func NewArchive() *Archive {
+	return &Archive{entries: make(map[string]*Entry)}
+}
+
+// Reads an archive written by Save
+func LoadArchive(path string) (*Archive, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	archive := NewArchive()
+	if err := json.Unmarshal(data, &archive.entries); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// Writes the archive to path as json.
+// Writes to a temporary file first and renames it so a crash never leaves a half written archive
+func (a *Archive) Save(path string) error {
+	a.mu.Lock()
+	data, err := json.MarshalIndent(a.entries, "", "  ")
+	a.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Returns the number of recorded responses
+func (a *Archive) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.entries)
+}
+
+func archiveKey(req *http.Request) string {
+	return req.Method + " " + req.URL.String()
+}
+
+// Returns a transport that sends requests with next, or http.DefaultTransport if it is nil,
+// and records each response in the archive. A url requested again replaces its earlier response
+func (a *Archive) Recorder(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &recorder{a, next}
+}
+
+// Returns a transport that answers requests from the archive without any network traffic.
+// Requests that were not recorded fail with *NotRecordedErr
+func (a *Archive) Replayer() http.RoundTripper {
+	return &replayer{a}
+}
+
+type recorder struct {
+	archive *Archive
+	next    http.RoundTripper
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	r.archive.mu.Lock()
+	r.archive.entries[archiveKey(req)] = &Entry{res.StatusCode, res.Header.Clone(), body}
+	r.archive.mu.Unlock()
+
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return res, nil
+}
+
+type replayer struct {
+	archive *Archive
+}
+
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.archive.mu.Lock()
+	entry, ok := r.archive.entries[archiveKey(req)]
+	r.archive.mu.Unlock()
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	if !ok {
+		return nil, &NotRecordedErr{req.Method, req.URL.String()}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.Status, http.StatusText(entry.Status)),
+		StatusCode:    entry.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,173 @@
+package offline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alis93/hn-scraper/crawler"
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+const (
+	LISTS_FILE = "lists.json"
+	// How many items SaveSnapshot fetches at once
+	SNAPSHOT_WORKERS = 8
+)
+
+// Story lists and their items as they were at one point in time.
+// The lists are stored in LISTS_FILE and the items in crawl shards, so a snapshot
+// can also be made from a crawl output directory by adding a LISTS_FILE to it
+type Snapshot struct {
+	Time  time.Time        `json:"time"`
+	Lists map[string][]int `json:"lists"`
+	items map[int]*hackernews.RawItem
+}
+
+var _ hackernews.Source = &Snapshot{}
+
+// Reads the snapshot saved in dir.
+// Returns error if dir has no LISTS_FILE or no items
+func LoadSnapshot(dir string) (*Snapshot, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, LISTS_FILE))
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{items: make(map[int]*hackernews.RawItem)}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, err
+	}
+
+	err = crawler.ReadSnapshot(dir, func(item *hackernews.RawItem) error {
+		snapshot.items[item.ID] = item
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Saves the first n ids of each list and their items from source into dir.
+// Returns error if any list or item cannot be read, so a snapshot is never missing items
+func SaveSnapshot(ctx context.Context, source hackernews.Source, dir string, lists []string, n int) (*Snapshot, error) {
+	snapshot := &Snapshot{
+		Time:  time.Now().UTC(),
+		Lists: make(map[string][]int),
+		items: make(map[int]*hackernews.RawItem),
+	}
+
+	wanted := []int{}
+	for _, list := range lists {
+		ids, err := source.ListIDs(ctx, list, n)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Lists[list] = ids
+		for _, id := range ids {
+			if _, ok := snapshot.items[id]; !ok {
+				snapshot.items[id] = nil
+				wanted = append(wanted, id)
+			}
+		}
+	}
+
+	ids := make(chan int)
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	for i := 0; i < SNAPSHOT_WORKERS; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range ids {
+				item, err := source.Item(ctx, id)
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = fmt.Errorf("unable to save item %d. %w", id, err)
+				}
+				snapshot.items[id] = item
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, id := range wanted {
+		ids <- id
+	}
+	close(ids)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	if err := snapshot.save(dir); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Writes the items as a single shard in id order, then the lists
+func (s *Snapshot) save(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	items := make([]*hackernews.RawItem, 0, len(s.items))
+	for _, item := range s.items {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+
+	shard, err := os.Create(filepath.Join(dir, fmt.Sprintf(crawler.SHARD_TEMPLATE, 0)))
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(shard)
+	for _, item := range items {
+		if err := encoder.Encode(item); err != nil {
+			shard.Close()
+			return err
+		}
+	}
+	if err := shard.Close(); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, LISTS_FILE), data, 0644)
+}
+
+// Returns the first n ids the list had when the snapshot was saved.
+// Returns *MissingListErr if the list was not saved
+func (s *Snapshot) ListIDs(ctx context.Context, list string, n int) ([]int, error) {
+	if err := hackernews.ValidateList(list, n); err != nil {
+		return nil, err
+	}
+	ids, ok := s.Lists[list]
+	if !ok {
+		return nil, &MissingListErr{list, LISTS_FILE}
+	}
+	if n < len(ids) {
+		ids = ids[:n]
+	}
+	return append([]int{}, ids...), nil
+}
+
+// Returns a copy of the saved item. Returns *hackernews.MissingItemErr if it was not saved
+func (s *Snapshot) Item(ctx context.Context, id int) (*hackernews.RawItem, error) {
+	item, ok := s.items[id]
+	if !ok {
+		return nil, hackernews.NewMissingItemErr(id)
+	}
+	copied := *item
+	return &copied, nil
+}
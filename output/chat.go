@@ -0,0 +1,356 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+// Limits of each platform's messages
+const (
+	SLACK_MAX_BLOCKS      = 50
+	SLACK_MAX_HEADER      = 150
+	SLACK_MAX_SECTION     = 3000
+	DISCORD_MAX_EMBEDS    = 10
+	DISCORD_MAX_CONTENT   = 2000
+	DISCORD_MAX_TITLE     = 256
+	DISCORD_MAX_TOTAL     = 6000
+	DISCORD_COLOUR        = 0xff6600
+	MATRIX_MAX_BYTES      = 60000 // events are limited to 65536 bytes including the envelope added by the server
+	HN_ITEM_URL           = "https://news.ycombinator.com/item?id=%d"
+	chatPartSuffixReserve = 10 // room for " (12/34)"
+)
+
+// Renders stories as messages for a chat platform
+type ChatFormatter interface {
+	// Returns the json payloads of one or more messages, split to fit the platform's limits
+	Format(title string, stories []*hackernews.Story) ([][]byte, error)
+	// Returns the request that posts a payload to the webhook url.
+	// part is the index of the payload, used to make each request unique
+	NewRequest(ctx context.Context, webhookURL string, payload []byte, part int) (*http.Request, error)
+}
+
+// The chat formatters by platform name
+var ChatFormatters = map[string]ChatFormatter{
+	"discord": DiscordFormatter{},
+	"matrix":  MatrixFormatter{},
+	"slack":   SlackFormatter{},
+}
+
+// Posts each payload to the webhook in order, retrying according to the policy.
+// Stops at the first payload that fails
+func PostChat(ctx context.Context, hc *http.Client, retry hackernews.RetryPolicy, formatter ChatFormatter, webhookURL string, payloads [][]byte) error {
+	for i, payload := range payloads {
+		req, err := formatter.NewRequest(ctx, webhookURL, payload, i)
+		if err != nil {
+			return err
+		}
+		res, err := retry.Do(hc, req, nil)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			return &ChatPostErr{i + 1, len(payloads), res.StatusCode}
+		}
+	}
+	return nil
+}
+
+// Posts payload as json
+func postJSON(ctx context.Context, method, webhookURL string, payload []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// Returns the title of one of several messages, for example "Top stories (2/3)"
+func partTitle(title string, part, parts int) string {
+	if parts == 1 {
+		return title
+	}
+	return fmt.Sprintf("%s (%d/%d)", title, part+1, parts)
+}
+
+// Cuts s to at most max characters, ending with … if anything was cut
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:max-1]) + "…"
+}
+
+// Escapes s and shortens it to max characters with an ellipsis, never cutting an escaped character in half
+func truncateEscaped(s string, max int, escape func(string) string) string {
+	if escaped := escape(s); utf8.RuneCountInString(escaped) <= max {
+		return escaped
+	}
+	var b strings.Builder
+	length := 0
+	for _, r := range s {
+		escaped := escape(string(r))
+		n := utf8.RuneCountInString(escaped)
+		if length+n > max-1 {
+			break
+		}
+		b.WriteString(escaped)
+		length += n
+	}
+	return b.String() + "…"
+}
+
+// The line under each story title
+func storyDetails(story *hackernews.Story) string {
+	return fmt.Sprintf("%d points by %s", story.Points, story.Author)
+}
+
+func commentsURL(story *hackernews.Story) string {
+	return fmt.Sprintf(HN_ITEM_URL, story.ID)
+}
+
+// Formats stories as Slack Block Kit messages, posted to an incoming webhook
+type SlackFormatter struct{}
+
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type string     `json:"type"`
+	Text *slackText `json:"text,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Escapes the characters Slack treats as markup
+func slackEscape(s string) string {
+	s = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+	// | separates a link from its text
+	return strings.ReplaceAll(s, "|", "¦")
+}
+
+// Escapes a url for the link part of <url|text>. | is percent encoded so the url still works
+func slackEscapeURL(url string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "|", "%7C").Replace(url)
+}
+
+func (SlackFormatter) Format(title string, stories []*hackernews.Story) ([][]byte, error) {
+	sections := []slackBlock{}
+	for _, story := range stories {
+		if story == nil {
+			continue
+		}
+		link := slackEscapeURL(story.URL)
+		if story.URL == "" || utf8.RuneCountInString(link) > SLACK_MAX_SECTION/2 {
+			// a url too long to leave room for the title links to the comments instead
+			link = commentsURL(story)
+		}
+		details := fmt.Sprintf("\n%s | <%s|%d comments>", slackEscape(storyDetails(story)), commentsURL(story), story.Comments)
+		// only the title is cut, so the link markup around it stays whole
+		markup := utf8.RuneCountInString(link) + utf8.RuneCountInString(details) + len("*<|>*")
+		text := fmt.Sprintf("*<%s|%s>*%s", link, truncateEscaped(story.Title, SLACK_MAX_SECTION-markup, slackEscape), details)
+		sections = append(sections, slackBlock{Type: "section", Text: &slackText{"mrkdwn", text}})
+	}
+
+	// one block of each message is the header
+	chunks := chunk(len(sections), SLACK_MAX_BLOCKS-1)
+	payloads := make([][]byte, len(chunks))
+	for i, c := range chunks {
+		header := truncate(partTitle(title, i, len(chunks)), SLACK_MAX_HEADER)
+		blocks := append([]slackBlock{{Type: "header", Text: &slackText{"plain_text", header}}}, sections[c[0]:c[1]]...)
+		payload, err := json.Marshal(slackMessage{Text: header, Blocks: blocks})
+		if err != nil {
+			return nil, err
+		}
+		payloads[i] = payload
+	}
+	return payloads, nil
+}
+
+func (SlackFormatter) NewRequest(ctx context.Context, webhookURL string, payload []byte, part int) (*http.Request, error) {
+	return postJSON(ctx, "POST", webhookURL, payload)
+}
+
+// Formats stories as Discord embeds, posted to a channel webhook
+type DiscordFormatter struct{}
+
+type discordMessage struct {
+	Content string         `json:"content"`
+	Embeds  []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string `json:"title"`
+	URL         string `json:"url,omitempty"`
+	Description string `json:"description"`
+	Color       int    `json:"color"`
+}
+
+// Counted towards DISCORD_MAX_TOTAL
+func (e discordEmbed) size() int {
+	return utf8.RuneCountInString(e.Title) + utf8.RuneCountInString(e.Description)
+}
+
+func (DiscordFormatter) Format(title string, stories []*hackernews.Story) ([][]byte, error) {
+	embeds := []discordEmbed{}
+	for _, story := range stories {
+		if story == nil {
+			continue
+		}
+		embeds = append(embeds, discordEmbed{
+			Title:       truncate(story.Title, DISCORD_MAX_TITLE),
+			URL:         story.URL,
+			Description: fmt.Sprintf("%s | [%d comments](%s)", storyDetails(story), story.Comments, commentsURL(story)),
+			Color:       DISCORD_COLOUR,
+		})
+	}
+
+	// split on the number of embeds and their total size
+	messages := [][]discordEmbed{}
+	current, size := []discordEmbed{}, 0
+	budget := DISCORD_MAX_TOTAL
+	for _, embed := range embeds {
+		if len(current) == DISCORD_MAX_EMBEDS || (len(current) > 0 && size+embed.size() > budget) {
+			messages = append(messages, current)
+			current, size = []discordEmbed{}, 0
+		}
+		current = append(current, embed)
+		size += embed.size()
+	}
+	messages = append(messages, current)
+
+	payloads := make([][]byte, len(messages))
+	for i, message := range messages {
+		payload, err := json.Marshal(discordMessage{
+			Content: truncate(partTitle(title, i, len(messages)), DISCORD_MAX_CONTENT),
+			Embeds:  message,
+		})
+		if err != nil {
+			return nil, err
+		}
+		payloads[i] = payload
+	}
+	return payloads, nil
+}
+
+func (DiscordFormatter) NewRequest(ctx context.Context, webhookURL string, payload []byte, part int) (*http.Request, error) {
+	return postJSON(ctx, "POST", webhookURL, payload)
+}
+
+// Formats stories as Matrix m.room.message events with an html body.
+// The webhook url is the client-server API send endpoint of a room, for example
+// https://matrix.example.org/_matrix/client/v3/rooms/!room:example.org/send/m.room.message?access_token=...
+type MatrixFormatter struct{}
+
+type matrixMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format"`
+	FormattedBody string `json:"formatted_body"`
+}
+
+func (MatrixFormatter) Format(title string, stories []*hackernews.Story) ([][]byte, error) {
+	type item struct{ text, html string }
+	items := []item{}
+	for _, story := range stories {
+		if story == nil {
+			continue
+		}
+		link := story.URL
+		if link == "" {
+			link = commentsURL(story)
+		}
+		items = append(items, item{
+			text: fmt.Sprintf("%s\n%s\n%s | %d comments: %s", story.Title, link, storyDetails(story), story.Comments, commentsURL(story)),
+			html: fmt.Sprintf(`<li><a href="%s">%s</a><br>%s | <a href="%s">%d comments</a></li>`,
+				html.EscapeString(link), html.EscapeString(story.Title), html.EscapeString(storyDetails(story)),
+				commentsURL(story), story.Comments),
+		})
+	}
+
+	build := func(title string, items []item) matrixMessage {
+		texts, htmls := make([]string, len(items)), make([]string, len(items))
+		for i, it := range items {
+			texts[i], htmls[i] = it.text, it.html
+		}
+		return matrixMessage{
+			MsgType:       "m.text",
+			Body:          title + "\n\n" + strings.Join(texts, "\n\n"),
+			Format:        "org.matrix.custom.html",
+			FormattedBody: "<h3>" + html.EscapeString(title) + "</h3><ol>" + strings.Join(htmls, "") + "</ol>",
+		}
+	}
+	// json escapes each character on its own, so the encoded size of a message is
+	// the size of an empty one plus the encoded size of each item
+	encodedLen := func(s string) int {
+		data, _ := json.Marshal(s)
+		return len(data) - 2
+	}
+	empty, _ := json.Marshal(build(title, nil))
+	budget := MATRIX_MAX_BYTES - len(empty) - chatPartSuffixReserve*2
+
+	messages := [][]item{}
+	current, size := []item{}, 0
+	for _, it := range items {
+		cost := encodedLen("\n\n"+it.text) + encodedLen(it.html)
+		if len(current) > 0 && size+cost > budget {
+			messages = append(messages, current)
+			current, size = []item{}, 0
+		}
+		current = append(current, it)
+		size += cost
+	}
+	messages = append(messages, current)
+
+	payloads := make([][]byte, len(messages))
+	for i, message := range messages {
+		payload, err := json.Marshal(build(partTitle(title, i, len(messages)), message))
+		if err != nil {
+			return nil, err
+		}
+		if len(payload) > MATRIX_MAX_BYTES {
+			return nil, &MessageTooLargeErr{"matrix", len(payload), MATRIX_MAX_BYTES}
+		}
+		payloads[i] = payload
+	}
+	return payloads, nil
+}
+
+// Sends the event with PUT, adding a transaction id to the path so retries are not posted twice
+func (MatrixFormatter) NewRequest(ctx context.Context, webhookURL string, payload []byte, part int) (*http.Request, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + fmt.Sprintf("/hn%d-%d", time.Now().UnixNano(), part)
+	return postJSON(ctx, "PUT", u.String(), payload)
+}
+
+// Splits n items into [start, end) ranges of at most size items. Always returns at least one range
+func chunk(n, size int) [][2]int {
+	chunks := [][2]int{}
+	for start := 0; start < n; start += size {
+		chunks = append(chunks, [2]int{start, min(start+size, n)})
+	}
+	if len(chunks) == 0 {
+		chunks = append(chunks, [2]int{0, 0})
+	}
+	return chunks
+}
@@ -0,0 +1,245 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+// Returns n stories with titles of the given length
+func testStories(n, titleLength int) []*hackernews.Story {
+	stories := make([]*hackernews.Story, n)
+	for i := range stories {
+		stories[i] = &hackernews.Story{
+			ID:       i + 1,
+			Title:    fmt.Sprintf("%d <b>&|", i+1) + strings.Repeat("é", titleLength),
+			URL:      fmt.Sprintf("https://example.com/%d", i+1),
+			Author:   "pg",
+			Points:   100 + i,
+			Comments: i,
+		}
+	}
+	return stories
+}
+
+func TestSlackFormatter(t *testing.T) {
+	log.Println("Testing Slack messages")
+
+	payloads, err := SlackFormatter{}.Format("Top stories", testStories(120, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payloads) != 3 {
+		t.Fatalf("Expected 120 stories to split into 3 messages but got %d", len(payloads))
+	}
+
+	sections := 0
+	for i, payload := range payloads {
+		message := slackMessage{}
+		if err := json.Unmarshal(payload, &message); err != nil {
+			t.Fatal(err)
+		}
+		if len(message.Blocks) > SLACK_MAX_BLOCKS {
+			t.Errorf("Message %d has %d blocks", i, len(message.Blocks))
+		}
+		if header := fmt.Sprintf("Top stories (%d/3)", i+1); message.Blocks[0].Text.Text != header || message.Text != header {
+			t.Errorf("Header incorrect. Expected %q Actual %q", header, message.Blocks[0].Text.Text)
+		}
+		sections += len(message.Blocks) - 1
+	}
+	if sections != 120 {
+		t.Errorf("Expected 120 sections but got %d", sections)
+	}
+
+	message := slackMessage{}
+	json.Unmarshal(payloads[0], &message)
+	expected := "*<https://example.com/1|1 &lt;b&gt;&amp;¦" + strings.Repeat("é", 10) + ">*\n100 points by pg | <https://news.ycombinator.com/item?id=1|0 comments>"
+	if text := message.Blocks[1].Text.Text; text != expected {
+		t.Errorf("Section incorrect. \n\t Expected %q \n\t Actual %q", expected, text)
+	}
+
+	// markup in the url is escaped and | is percent encoded so it does not end the link
+	stories := testStories(1, 0)
+	stories[0].URL = "https://example.com/search?q=a|b&sort=<new>"
+	payloads, _ = SlackFormatter{}.Format("Top", stories)
+	json.Unmarshal(payloads[0], &message)
+	expectedLink := "*<https://example.com/search?q=a%7Cb&amp;sort=&lt;new&gt;|1 &lt;b&gt;&amp;¦>*"
+	if text := message.Blocks[1].Text.Text; !strings.HasPrefix(text, expectedLink) {
+		t.Errorf("Section link incorrect. \n\t Expected prefix %q \n\t Actual %q", expectedLink, text)
+	}
+
+	// a long title is cut to fit a section
+	payloads, _ = SlackFormatter{}.Format("Top", testStories(1, 5000))
+	json.Unmarshal(payloads[0], &message)
+	if n := utf8.RuneCountInString(message.Blocks[1].Text.Text); len(payloads) != 1 || n > SLACK_MAX_SECTION {
+		t.Errorf("Expected one message with a section of at most %d characters but got %d", SLACK_MAX_SECTION, n)
+	}
+	// the title is cut, not the link markup or the details after it
+	section := message.Blocks[1].Text.Text
+	if !strings.HasPrefix(section, "*<https://example.com/1|1 &lt;b&gt;&amp;¦") || !strings.HasSuffix(section, "…>*\n100 points by pg | <https://news.ycombinator.com/item?id=1|0 comments>") {
+		t.Errorf("Expected a cut title inside whole link markup but got %q", section)
+	}
+
+	// a title of escaped characters is never cut inside an escape
+	stories = testStories(1, 0)
+	stories[0].Title = strings.Repeat("&", 2000)
+	payloads, _ = SlackFormatter{}.Format("Top", stories)
+	json.Unmarshal(payloads[0], &message)
+	section = message.Blocks[1].Text.Text
+	if n := utf8.RuneCountInString(section); n > SLACK_MAX_SECTION || !strings.Contains(section, "&amp;…>*") {
+		t.Errorf("Expected a title of whole escapes cut to fit, got %d characters ending %q", n, section[len(section)-120:])
+	}
+}
+
+func TestDiscordFormatter(t *testing.T) {
+	log.Println("Testing Discord messages")
+
+	// long authors push the embeds over the total size before the embed count
+	long := testStories(10, 1000)
+	for _, story := range long {
+		story.Author = strings.Repeat("a", 1000)
+	}
+
+	splitTests := []struct {
+		name     string
+		stories  []*hackernews.Story
+		expected int
+	}{
+		{"embed count", testStories(25, 10), 3},
+		{"total size", long, 3},
+		{"empty", nil, 1},
+	}
+
+	for _, test := range splitTests {
+		t.Run(test.name, func(t *testing.T) {
+			payloads, err := DiscordFormatter{}.Format("Top stories", test.stories)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(payloads) != test.expected {
+				t.Fatalf("Expected %d messages but got %d", test.expected, len(payloads))
+			}
+
+			embeds := 0
+			for _, payload := range payloads {
+				message := discordMessage{}
+				json.Unmarshal(payload, &message)
+				total := 0
+				for _, embed := range message.Embeds {
+					total += embed.size()
+					if utf8.RuneCountInString(embed.Title) > DISCORD_MAX_TITLE || embed.Color != DISCORD_COLOUR {
+						t.Errorf("Embed incorrect. %+v", embed)
+					}
+				}
+				if len(message.Embeds) > DISCORD_MAX_EMBEDS || total > DISCORD_MAX_TOTAL {
+					t.Errorf("Message over limits with %d embeds and %d characters", len(message.Embeds), total)
+				}
+				embeds += len(message.Embeds)
+			}
+			if embeds != len(test.stories) {
+				t.Errorf("Expected %d embeds but got %d", len(test.stories), embeds)
+			}
+		})
+	}
+}
+
+func TestMatrixFormatter(t *testing.T) {
+	log.Println("Testing Matrix messages")
+
+	payloads, err := MatrixFormatter{}.Format("Top stories", testStories(300, 200))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payloads) < 2 {
+		t.Fatalf("Expected 300 long stories to need several messages but got %d", len(payloads))
+	}
+
+	items := 0
+	for i, payload := range payloads {
+		if len(payload) > MATRIX_MAX_BYTES {
+			t.Errorf("Message %d is %d bytes", i, len(payload))
+		}
+		message := matrixMessage{}
+		json.Unmarshal(payload, &message)
+		if message.MsgType != "m.text" || message.Format != "org.matrix.custom.html" {
+			t.Errorf("Message type incorrect. %+v", message)
+		}
+		if title := fmt.Sprintf("<h3>Top stories (%d/%d)</h3>", i+1, len(payloads)); !strings.HasPrefix(message.FormattedBody, title) {
+			t.Errorf("Title incorrect. Expected %q", title)
+		}
+		items += strings.Count(message.FormattedBody, "<li>")
+	}
+	if items != 300 {
+		t.Errorf("Expected 300 stories but got %d", items)
+	}
+
+	payloads, _ = MatrixFormatter{}.Format("Top", testStories(1, 1))
+	message := matrixMessage{}
+	json.Unmarshal(payloads[0], &message)
+	expected := `<h3>Top</h3><ol><li><a href="https://example.com/1">1 &lt;b&gt;&amp;|é</a><br>100 points by pg | <a href="https://news.ycombinator.com/item?id=1">0 comments</a></li></ol>`
+	if message.FormattedBody != expected {
+		t.Errorf("Html body incorrect. \n\t Expected %q \n\t Actual %q", expected, message.FormattedBody)
+	}
+	if !strings.HasPrefix(message.Body, "Top\n\n1 <b>&|é\nhttps://example.com/1\n") {
+		t.Errorf("Plain body incorrect. %q", message.Body)
+	}
+}
+
+func TestPostChat(t *testing.T) {
+	log.Println("Testing posting chat messages")
+
+	var mu sync.Mutex
+	requests := []string{}
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		// rate limit the first request
+		if fail {
+			fail = false
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body[:1]))
+		if strings.Contains(r.URL.Path, "refuse") {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	retry := hackernews.RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond}
+	payloads, _ := SlackFormatter{}.Format("Top", testStories(60, 5))
+	if err := PostChat(context.Background(), server.Client(), retry, SlackFormatter{}, server.URL+"/hooks/slack", payloads); err != nil {
+		t.Fatalf("Failed to post. Reason : %s", err.Error())
+	}
+	if len(requests) != 2 || requests[0] != "POST /hooks/slack {" {
+		t.Errorf("Requests incorrect. %v", requests)
+	}
+
+	requests = nil
+	payloads, _ = MatrixFormatter{}.Format("Top", testStories(2, 5))
+	roomURL := server.URL + "/_matrix/client/v3/rooms/!room:example.org/send/m.room.message?access_token=secret"
+	if err := PostChat(context.Background(), server.Client(), retry, MatrixFormatter{}, roomURL, payloads); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 || !strings.HasPrefix(requests[0], "PUT /_matrix/client/v3/rooms/!room:example.org/send/m.room.message/hn") {
+		t.Errorf("Matrix request incorrect. %v", requests)
+	}
+
+	err := PostChat(context.Background(), server.Client(), retry, DiscordFormatter{}, server.URL+"/refuse", payloads)
+	if _, ok := err.(*ChatPostErr); !ok {
+		t.Errorf("Expected *ChatPostErr but got %v", err)
+	}
+}
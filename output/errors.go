@@ -0,0 +1,22 @@
+package output
+
+import "fmt"
+
+type ChatPostErr struct {
+	part, parts int
+	statusCode  int
+}
+
+func (e *ChatPostErr) Error() string {
+	return fmt.Sprintf("Message %d of %d was refused with status code %d", e.part, e.parts, e.statusCode)
+}
+
+type MessageTooLargeErr struct {
+	platform string
+	size     int
+	max      int
+}
+
+func (e *MessageTooLargeErr) Error() string {
+	return fmt.Sprintf("A single story makes a %s message of %d bytes, more than the limit of %d", e.platform, e.size, e.max)
+}
@@ -0,0 +1,40 @@
+package pipeline
+
+import "fmt"
+
+var (
+	NoSourceErr       = fmt.Errorf("Pipeline needs a source to read stories from")
+	NoConverterErr    = fmt.Errorf("Pipeline needs a converter to turn items into stories")
+	InvalidWorkersErr = fmt.Errorf("Number of workers must be more than 0")
+)
+
+type UnknownStageErr struct {
+	stage string
+}
+
+type UnknownPolicyErr struct {
+	policy string
+}
+
+// A story that failed a stage whose policy is POLICY_STOP
+type StageErr struct {
+	stage string
+	id    int
+	err   error
+}
+
+func (e *UnknownStageErr) Error() string {
+	return fmt.Sprintf("Unknown stage %q. Stages with workers and policies are %s, %s, %s and %s", e.stage, STAGE_FETCH, STAGE_CONVERT, STAGE_FILTER, STAGE_ENRICH)
+}
+
+func (e *UnknownPolicyErr) Error() string {
+	return fmt.Sprintf("Unknown error policy %q. Must be %s, %s or %s", e.policy, POLICY_REPORT, POLICY_DROP, POLICY_STOP)
+}
+
+func (e *StageErr) Error() string {
+	return fmt.Sprintf("Story %d failed at %s. \t %s", e.id, e.stage, e.err.Error())
+}
+
+func (e *StageErr) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,306 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+// The stages a story goes through after its id is listed, in order.
+// Results are then sorted, if WithSort is used, and sent to the sinks
+const (
+	STAGE_FETCH   = hackernews.STAGE_FETCH
+	STAGE_CONVERT = hackernews.STAGE_CONVERT
+	STAGE_FILTER  = "filter"
+	STAGE_ENRICH  = "enrich"
+)
+
+// What a stage does with a story that fails it
+const (
+	// Passes the failed result on to the sinks. The default
+	POLICY_REPORT = "report"
+	// Logs the failure and leaves the story out
+	POLICY_DROP = "drop"
+	// Stops the run, Run returns the failure as a StageErr
+	POLICY_STOP = "stop"
+)
+
+// Default number of workers of each stage
+var DefaultWorkers = map[string]int{
+	STAGE_FETCH:   32,
+	STAGE_CONVERT: 4,
+	STAGE_FILTER:  1,
+	STAGE_ENRICH:  8,
+}
+
+// Rejects a story by returning an error
+type Filter func(story *hackernews.Story) error
+
+// Adds to a story, for example by fetching its article. An error fails the story
+type Enricher func(ctx context.Context, story *hackernews.Story) error
+
+// Receives every result that reaches the end of the pipeline.
+// Sinks are called from a single goroutine. An error stops the run
+type Sink func(result hackernews.Result) error
+
+// Orders results before they are sent to the sinks
+type Less func(a, b hackernews.Result) bool
+
+// Orders results by their rank in the list
+func ByRank(a, b hackernews.Result) bool {
+	return a.Rank < b.Rank
+}
+
+// Lists, fetches, converts, filters and enriches stories, then sends each result to the sinks.
+// Every stage runs its own workers, and stories move between stages as soon as they are ready
+type Pipeline struct {
+	source    hackernews.Source
+	converter *hackernews.ItemConverter
+	workers   map[string]int
+	policies  map[string]string
+	filters   []Filter
+	enrichers []Enricher
+	less      Less
+	sinks     []Sink
+	logger    *slog.Logger
+}
+
+// Optional settings applied when creating a pipeline
+type Option func(*Pipeline)
+
+// Sets how many stories a stage works on at once.
+func WithWorkers(stage string, workers int) Option {
+	return func(p *Pipeline) {
+		p.workers[stage] = workers
+	}
+}
+
+// Sets what a stage does with failed stories. By default they are reported to the sinks.
+func WithPolicy(stage, policy string) Option {
+	return func(p *Pipeline) {
+		p.policies[stage] = policy
+	}
+}
+
+// Adds a filter. Filters run in the order they are added.
+func WithFilter(filter Filter) Option {
+	return func(p *Pipeline) {
+		p.filters = append(p.filters, filter)
+	}
+}
+
+// Adds an enricher. Enrichers run in the order they are added.
+func WithEnricher(enricher Enricher) Option {
+	return func(p *Pipeline) {
+		p.enrichers = append(p.enrichers, enricher)
+	}
+}
+
+// Holds every result until the run is done and sends them to the sinks ordered by less.
+// Without it results are sent as soon as they are ready.
+func WithSort(less Less) Option {
+	return func(p *Pipeline) {
+		p.less = less
+	}
+}
+
+// Adds a sink. Each result is sent to the sinks in the order they are added.
+func WithSink(sink Sink) Option {
+	return func(p *Pipeline) {
+		p.sinks = append(p.sinks, sink)
+	}
+}
+
+// Logs failed stories to logger. By default nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *Pipeline) {
+		p.logger = logger
+	}
+}
+
+// Creates a pipeline reading stories from source and converting them with converter
+func NewPipeline(source hackernews.Source, converter *hackernews.ItemConverter, opts ...Option) (*Pipeline, error) {
+	if source == nil {
+		return nil, NoSourceErr
+	}
+	if converter == nil {
+		return nil, NoConverterErr
+	}
+
+	p := &Pipeline{
+		source:    source,
+		converter: converter,
+		workers:   map[string]int{},
+		policies:  map[string]string{},
+		logger:    slog.New(slog.DiscardHandler),
+	}
+	for stage, workers := range DefaultWorkers {
+		p.workers[stage] = workers
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	for stage, workers := range p.workers {
+		if _, ok := DefaultWorkers[stage]; !ok {
+			return nil, &UnknownStageErr{stage}
+		}
+		if workers <= 0 {
+			return nil, InvalidWorkersErr
+		}
+	}
+	for stage, policy := range p.policies {
+		if _, ok := DefaultWorkers[stage]; !ok {
+			return nil, &UnknownStageErr{stage}
+		}
+		if policy != POLICY_REPORT && policy != POLICY_DROP && policy != POLICY_STOP {
+			return nil, &UnknownPolicyErr{policy}
+		}
+	}
+	return p, nil
+}
+
+// Runs the pipeline over the first n stories of list.
+// Returns an error if the list cannot be read, a sink fails, a story fails a stage with POLICY_STOP
+// or ctx is cancelled.
+func (p *Pipeline) Run(ctx context.Context, list string, n int) error {
+	ids, err := p.source.ListIDs(ctx, list, n)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var stopOnce sync.Once
+	var stopErr error
+	stop := func(err error) {
+		stopOnce.Do(func() {
+			stopErr = err
+			cancel()
+		})
+	}
+
+	listed := make(chan *hackernews.Result)
+	go func() {
+		defer close(listed)
+		for index, id := range ids {
+			r := &hackernews.Result{Rank: index + 1, ID: id}
+			select {
+			case listed <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	fetched := p.stage(ctx, STAGE_FETCH, listed, stop, func(ctx context.Context, r *hackernews.Result) error {
+		item, err := p.source.Item(ctx, r.ID)
+		r.Item = item
+		return err
+	})
+	converted := p.stage(ctx, STAGE_CONVERT, fetched, stop, func(ctx context.Context, r *hackernews.Result) error {
+		story, err := p.converter.Convert(r.Rank, r.Item)
+		r.Story = story
+		return err
+	})
+	filtered := p.stage(ctx, STAGE_FILTER, converted, stop, func(ctx context.Context, r *hackernews.Result) error {
+		for _, filter := range p.filters {
+			if err := filter(r.Story); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	enriched := p.stage(ctx, STAGE_ENRICH, filtered, stop, func(ctx context.Context, r *hackernews.Result) error {
+		for _, enricher := range p.enrichers {
+			if err := enricher(ctx, r.Story); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	send := func(result hackernews.Result) {
+		for _, sink := range p.sinks {
+			if err := sink(result); err != nil {
+				stop(err)
+				return
+			}
+		}
+	}
+
+	var sorted []hackernews.Result
+	for r := range enriched {
+		if ctx.Err() != nil {
+			// drain the stages so their workers can exit
+			continue
+		}
+		if p.less != nil {
+			sorted = append(sorted, *r)
+			continue
+		}
+		send(*r)
+	}
+	if p.less != nil {
+		sort.SliceStable(sorted, func(i, k int) bool { return p.less(sorted[i], sorted[k]) })
+		for _, result := range sorted {
+			if ctx.Err() != nil {
+				break
+			}
+			send(result)
+		}
+	}
+
+	if stopErr != nil {
+		return stopErr
+	}
+	// the parent context was cancelled
+	return ctx.Err()
+}
+
+// Starts the workers of a stage, which call do on each result from in that has not failed yet.
+// Failed results are handled by the policy of the stage. Returns the channel the stage sends its results on,
+// which is closed once in is closed and every worker is done
+func (p *Pipeline) stage(ctx context.Context, name string, in <-chan *hackernews.Result, stop func(error), do func(context.Context, *hackernews.Result) error) <-chan *hackernews.Result {
+	out := make(chan *hackernews.Result)
+	policy := p.policies[name]
+	if policy == "" {
+		policy = POLICY_REPORT
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < p.workers[name]; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range in {
+				if !r.Failed() && ctx.Err() == nil {
+					if err := do(ctx, r); err != nil {
+						r.Err, r.Stage, r.Story = err, name, nil
+						p.logger.Warn("story failed", "story_id", r.ID, "rank", r.Rank, "stage", name, "error", err, "error_kind", hackernews.ErrorKind(err))
+						switch policy {
+						case POLICY_DROP:
+							continue
+						case POLICY_STOP:
+							stop(&StageErr{name, r.ID, err})
+							continue
+						}
+					}
+				}
+				// keep receiving after a cancel so the stage before can finish
+				select {
+				case out <- r:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
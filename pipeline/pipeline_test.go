@@ -0,0 +1,264 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/google/go-cmp/cmp"
+)
+
+// A source serving items from memory, failing the ids in errs
+type fakeSource struct {
+	ids   []int
+	items map[int]*hackernews.RawItem
+	errs  map[int]error
+	delay time.Duration
+
+	mu       sync.Mutex
+	inFlight int
+	maxSeen  int
+}
+
+func (s *fakeSource) ListIDs(ctx context.Context, list string, n int) ([]int, error) {
+	if list != "top" {
+		return nil, fmt.Errorf("unknown list %s", list)
+	}
+	if n > len(s.ids) {
+		n = len(s.ids)
+	}
+	return s.ids[:n], nil
+}
+
+func (s *fakeSource) Item(ctx context.Context, id int) (*hackernews.RawItem, error) {
+	s.mu.Lock()
+	s.inFlight++
+	if s.inFlight > s.maxSeen {
+		s.maxSeen = s.inFlight
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}()
+
+	time.Sleep(s.delay)
+	if err := s.errs[id]; err != nil {
+		return nil, err
+	}
+	return s.items[id], nil
+}
+
+var errFetch = errors.New("fetch failed")
+
+// Five stories: 2 cannot be fetched, 3 has no points and 4 is an Ask HN
+func helperSource() *fakeSource {
+	source := &fakeSource{ids: []int{1, 2, 3, 4, 5}, items: map[int]*hackernews.RawItem{}, errs: map[int]error{2: errFetch}}
+	for _, id := range source.ids {
+		source.items[id] = &hackernews.RawItem{ID: id, ItemType: "story", By: "moks", Title: fmt.Sprintf("Story %d", id), URL: fmt.Sprintf("https://example.com/%d", id), Score: 10, Descendants: 5}
+	}
+	source.items[3].Score = 0
+	source.items[4].Title = "Ask HN: Story 4"
+	return source
+}
+
+func helperConverter(t *testing.T) *hackernews.ItemConverter {
+	converter, err := hackernews.NewItemConverter(hackernews.DefaultConverterConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return converter
+}
+
+// Collects every result sent to the sinks
+type collector struct {
+	results []hackernews.Result
+}
+
+func (c *collector) sink(result hackernews.Result) error {
+	c.results = append(c.results, result)
+	return nil
+}
+
+// Returns "rank:title" for stories and "rank:stage" for failures
+func (c *collector) summary() []string {
+	summary := []string{}
+	for _, result := range c.results {
+		if result.Failed() {
+			summary = append(summary, fmt.Sprintf("%d:%s", result.Rank, result.Stage))
+			continue
+		}
+		summary = append(summary, fmt.Sprintf("%d:%s", result.Rank, result.Story.Title))
+	}
+	return summary
+}
+
+func TestPipeline(t *testing.T) {
+	log.Println("Testing pipeline stages")
+
+	noAsk := func(story *hackernews.Story) error {
+		if strings.HasPrefix(story.Title, "Ask HN") {
+			return errors.New("ask hn")
+		}
+		return nil
+	}
+	shout := func(ctx context.Context, story *hackernews.Story) error {
+		story.Title = strings.ToUpper(story.Title)
+		return nil
+	}
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected []string
+	}{
+		{
+			"report failures",
+			[]Option{WithFilter(noAsk), WithEnricher(shout)},
+			[]string{"1:STORY 1", "2:fetch", "3:convert", "4:filter", "5:STORY 5"},
+		},
+		{
+			"drop failures",
+			[]Option{WithFilter(noAsk), WithPolicy(STAGE_FETCH, POLICY_DROP), WithPolicy(STAGE_FILTER, POLICY_DROP)},
+			[]string{"1:Story 1", "3:convert", "5:Story 5"},
+		},
+		{
+			"one worker each",
+			[]Option{WithWorkers(STAGE_FETCH, 1), WithWorkers(STAGE_CONVERT, 1), WithWorkers(STAGE_ENRICH, 1)},
+			[]string{"1:Story 1", "2:fetch", "3:convert", "4:Ask HN: Story 4", "5:Story 5"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := &collector{}
+			opts := append([]Option{WithSort(ByRank), WithSink(results.sink)}, test.opts...)
+			p, err := NewPipeline(helperSource(), helperConverter(t), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Run(context.Background(), "top", 5); err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(results.summary(), test.expected) {
+				t.Errorf("Results incorrect. \n%s", cmp.Diff(test.expected, results.summary()))
+			}
+		})
+	}
+}
+
+func TestPipelineFailedResults(t *testing.T) {
+	log.Println("Testing failed pipeline results")
+
+	results := &collector{}
+	p, err := NewPipeline(helperSource(), helperConverter(t), WithSort(ByRank), WithSink(results.sink))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Run(context.Background(), "top", 3); err != nil {
+		t.Fatal(err)
+	}
+
+	fetch, convert := results.results[1], results.results[2]
+	if !errors.Is(fetch.Err, errFetch) || fetch.Item != nil || fetch.Story != nil {
+		t.Errorf("Expected fetch failure without item or story, got %+v", fetch)
+	}
+	var minErr *hackernews.MinValErr
+	if !errors.As(convert.Err, &minErr) || convert.Item == nil || convert.Story != nil {
+		t.Errorf("Expected convert failure with item and without story, got %+v", convert)
+	}
+}
+
+func TestPipelineStops(t *testing.T) {
+	log.Println("Testing pipeline stop policy and sink errors")
+
+	p, err := NewPipeline(helperSource(), helperConverter(t), WithPolicy(STAGE_FETCH, POLICY_STOP))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.Run(context.Background(), "top", 5)
+	var stageErr *StageErr
+	if !errors.As(err, &stageErr) || !errors.Is(err, errFetch) {
+		t.Errorf("Expected fetch StageErr, got %v", err)
+	}
+
+	full := errors.New("disk full")
+	sent := 0
+	p, _ = NewPipeline(helperSource(), helperConverter(t), WithSink(func(result hackernews.Result) error {
+		sent++
+		return full
+	}))
+	if err := p.Run(context.Background(), "top", 5); !errors.Is(err, full) {
+		t.Errorf("Expected sink error, got %v", err)
+	}
+	if sent != 1 {
+		t.Errorf("Expected the run to stop after the first sink error, but %d results were sent", sent)
+	}
+
+	if err := p.Run(context.Background(), "new", 5); err == nil {
+		t.Errorf("Expected unknown list to fail")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p, _ = NewPipeline(helperSource(), helperConverter(t))
+	if err := p.Run(ctx, "top", 5); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected cancelled run, got %v", err)
+	}
+}
+
+func TestPipelineWorkers(t *testing.T) {
+	log.Println("Testing pipeline fetch workers")
+
+	for _, workers := range []int{1, 3} {
+		source := helperSource()
+		source.delay = 5 * time.Millisecond
+		p, err := NewPipeline(source, helperConverter(t), WithWorkers(STAGE_FETCH, workers))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Run(context.Background(), "top", 5); err != nil {
+			t.Fatal(err)
+		}
+		if source.maxSeen > workers {
+			t.Errorf("Expected at most %d items fetched at once, got %d", workers, source.maxSeen)
+		}
+	}
+}
+
+func TestNewPipeline(t *testing.T) {
+	log.Println("Testing pipeline options")
+
+	source, converter := helperSource(), helperConverter(t)
+	tests := []struct {
+		name      string
+		source    hackernews.Source
+		converter *hackernews.ItemConverter
+		opts      []Option
+		valid     bool
+	}{
+		{"defaults", source, converter, nil, true},
+		{"no source", nil, converter, nil, false},
+		{"no converter", source, nil, nil, false},
+		{"no workers", source, converter, []Option{WithWorkers(STAGE_ENRICH, 0)}, false},
+		{"unknown stage", source, converter, []Option{WithWorkers("print", 1)}, false},
+		{"unknown policy", source, converter, []Option{WithPolicy(STAGE_FETCH, "retry")}, false},
+		{"unknown policy stage", source, converter, []Option{WithPolicy("print", POLICY_DROP)}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewPipeline(test.source, test.converter, test.opts...)
+			if valid := err == nil; valid != test.valid {
+				t.Errorf("Expected valid %v, got error %v", test.valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/alis93/hn-scraper/output"
+)
+
+// Posts the top stories of a list to a Slack, Discord or Matrix webhook, or prints the messages with --dry-run.
+func definePost(flags *flag.FlagSet) func(args []string) {
+	formats := make([]string, 0, len(output.ChatFormatters))
+	for name := range output.ChatFormatters {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+
+	format := flags.String("format", "slack", "Chat platform to format messages for. One of "+strings.Join(formats, ", "))
+	webhook := flags.String("webhook", "", "Incoming webhook url, or Matrix room send url. Defaults to $HN_WEBHOOK_URL")
+	list := flags.String("list", "top", "Story list to scrape. One of top, new, best, ask, show or job")
+	n := flags.Int("n", 10, "How many stories to post")
+	title := flags.String("title", "", "Title of the message. Defaults to the list name")
+	dedupe := flags.Bool("dedupe", false, "Group submissions of the same article, keeping the highest ranked")
+	dryRun := flags.Bool("dry-run", false, "Print the messages instead of posting them")
+	logs := addLogFlags(flags)
+	settings := addConfigFlags(flags)
+	sources := addSourceFlags(flags)
+	return func(args []string) {
+		logs.setup()
+		cfg := settings.load()
+
+		formatter, ok := output.ChatFormatters[*format]
+		if !ok {
+			fatal("unknown format", "format", *format)
+		}
+		if *webhook == "" {
+			*webhook = os.Getenv("HN_WEBHOOK_URL")
+		}
+		if *webhook == "" && !*dryRun {
+			fatal("--webhook or HN_WEBHOOK_URL is required")
+		}
+		if *title == "" {
+			*title = fmt.Sprintf("Hacker News %s stories", *list)
+		}
+
+		source := sources.open(cfg)
+		converter, err := newConverter(cfg.Converter)
+		if err != nil {
+			fatalErr(err)
+		}
+
+		stories, err := fetchStories(source, converter, *list, *n, nil)
+		if err != nil {
+			fatalErr(err)
+		}
+		sources.close()
+		if *dedupe {
+			stories = hackernews.Dedupe(stories, hackernews.DUPLICATE_TITLE_SIMILARITY)
+		}
+
+		payloads, err := formatter.Format(*title, stories)
+		if err != nil {
+			fatalErr(err)
+		}
+
+		if *dryRun {
+			for _, payload := range payloads {
+				fmt.Fprintln(os.Stdout, string(payload))
+			}
+			return
+		}
+
+		hc := &http.Client{Timeout: time.Duration(cfg.Client.Timeout) * time.Second}
+		if err := output.PostChat(context.Background(), hc, cfg.Client.RetryPolicy(), formatter, *webhook, payloads); err != nil {
+			fatalErr(err)
+		}
+		fmt.Fprintf(os.Stdout, "Posted %d stories in %d messages\n", len(stories), len(payloads))
+	}
+}
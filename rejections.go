@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+// A story that could not be retrieved or converted
+type rejection struct {
+	rank  int
+	id    int
+	title string
+	err   error
+}
+
+// Collects rejections from concurrent goroutines
+type rejections struct {
+	mu   sync.Mutex
+	list []rejection
+}
+
+func (r *rejections) add(rank, id int, item *hackernews.RawItem, err error) {
+	title := ""
+	if item != nil {
+		title = item.Title
+	}
+	r.mu.Lock()
+	r.list = append(r.list, rejection{rank, id, title, err})
+	r.mu.Unlock()
+}
+
+// Writes a report of every rejected story in rank order, with each failed check on its own line
+func (r *rejections) report(w io.Writer, total int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sort.Slice(r.list, func(i, j int) bool { return r.list[i].rank < r.list[j].rank })
+
+	fmt.Fprintf(w, "Rejected %d of %d stories\n", len(r.list), total)
+	for _, rej := range r.list {
+		fmt.Fprintf(w, "  #%d id %d %q\n", rej.rank, rej.id, rej.title)
+
+		var verrs hackernews.ValidationErrors
+		if !errors.As(rej.err, &verrs) {
+			fmt.Fprintf(w, "    %s: %s\n", hackernews.ErrorKind(rej.err), rej.err.Error())
+			continue
+		}
+		for _, fieldErr := range verrs {
+			fmt.Fprintf(w, "    %s: %s, value %q\n", fieldErr.Field, fieldErr.Rule, fmt.Sprint(fieldErr.Value))
+		}
+	}
+}
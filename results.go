@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+// Values of --fail-on, deciding when failed stories make the command exit with an error
+const (
+	FAIL_ON_ANY  = "any"
+	FAIL_ON_ALL  = "all"
+	FAIL_ON_NONE = "none"
+)
+
+// Counts the results of a run and how many failed at each stage
+type summary struct {
+	total  int
+	failed int
+	stages map[string]int
+}
+
+func (s *summary) add(result hackernews.Result) {
+	s.total++
+	if !result.Failed() {
+		return
+	}
+	if s.stages == nil {
+		s.stages = map[string]int{}
+	}
+	s.failed++
+	s.stages[result.Stage]++
+}
+
+// Writes a line such as "Failed 3 of 30 stories: 1 at fetch, 2 at convert". Writes nothing if none failed
+func (s *summary) write(w io.Writer) {
+	if s.failed == 0 {
+		return
+	}
+	stages := make([]string, 0, len(s.stages))
+	for stage := range s.stages {
+		stages = append(stages, stage)
+	}
+	sort.Strings(stages)
+	counts := make([]string, len(stages))
+	for i, stage := range stages {
+		counts[i] = fmt.Sprintf("%d at %s", s.stages[stage], stage)
+	}
+	fmt.Fprintf(w, "Failed %d of %d stories: %s\n", s.failed, s.total, strings.Join(counts, ", "))
+}
+
+// Returns true if the run should exit with an error under the --fail-on policy
+func (s *summary) fails(policy string) bool {
+	switch policy {
+	case FAIL_ON_ANY:
+		return s.failed > 0
+	case FAIL_ON_ALL:
+		return s.failed > 0 && s.failed == s.total
+	}
+	return false
+}
+
+func validFailOn(policy string) bool {
+	return policy == FAIL_ON_ANY || policy == FAIL_ON_ALL || policy == FAIL_ON_NONE
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+func TestSummary(t *testing.T) {
+	log.Println("Testing run summary and --fail-on policies")
+
+	failed := errors.New("failed")
+	tests := []struct {
+		name    string
+		results []hackernews.Result
+		output  string
+		fails   map[string]bool
+	}{
+		{
+			"none failed",
+			[]hackernews.Result{{Rank: 1, Story: &hackernews.Story{}}, {Rank: 2, Story: &hackernews.Story{}}},
+			"",
+			map[string]bool{FAIL_ON_ANY: false, FAIL_ON_ALL: false, FAIL_ON_NONE: false},
+		},
+		{
+			"some failed",
+			[]hackernews.Result{
+				{Rank: 1, Story: &hackernews.Story{}},
+				{Rank: 2, Err: failed, Stage: hackernews.STAGE_FETCH},
+				{Rank: 3, Err: failed, Stage: hackernews.STAGE_CONVERT},
+				{Rank: 4, Err: failed, Stage: hackernews.STAGE_CONVERT},
+			},
+			"Failed 3 of 4 stories: 2 at convert, 1 at fetch\n",
+			map[string]bool{FAIL_ON_ANY: true, FAIL_ON_ALL: false, FAIL_ON_NONE: false},
+		},
+		{
+			"all failed",
+			[]hackernews.Result{{Rank: 1, Err: failed, Stage: hackernews.STAGE_FETCH}},
+			"Failed 1 of 1 stories: 1 at fetch\n",
+			map[string]bool{FAIL_ON_ANY: true, FAIL_ON_ALL: true, FAIL_ON_NONE: false},
+		},
+		{
+			"no stories",
+			nil,
+			"",
+			map[string]bool{FAIL_ON_ANY: false, FAIL_ON_ALL: false, FAIL_ON_NONE: false},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &summary{}
+			for _, result := range test.results {
+				s.add(result)
+			}
+			var output bytes.Buffer
+			s.write(&output)
+			if output.String() != test.output {
+				t.Errorf("Expected summary %q but got %q", test.output, output.String())
+			}
+			for policy, expected := range test.fails {
+				if actual := s.fails(policy); actual != expected {
+					t.Errorf("Expected fails(%s) to be %v but got %v", policy, expected, actual)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package scraper
+
+import "fmt"
+
+var InvalidTimeOutErr = fmt.Errorf("Timeout must be more than 0 seconds")
+
+// A page with no stories, usually because the layout changed or the request was rate limited
+type NoStoriesErr struct {
+	url string
+}
+
+func (e *NoStoriesErr) Error() string {
+	return fmt.Sprintf("No stories found on %s", e.url)
+}
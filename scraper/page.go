@@ -0,0 +1,227 @@
+package scraper
+
+import (
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// A story as listed on a page of the site
+type Listing struct {
+	Rank int
+	Item *hackernews.RawItem
+	// The site shown after the title, for example github.com/golang
+	Site string
+	// How long ago the story was posted, as shown, for example "3 hours ago"
+	Age string
+}
+
+// The stories of a page and the link to the next page
+type Page struct {
+	Listings []Listing
+	// Absolute url of the "More" link. Empty on the last page
+	Next string
+}
+
+// Parses a list page such as /news, or an item page, read from r.
+// pageURL is used to resolve the story and "More" links
+func ParsePage(r io.Reader, pageURL string) (*Page, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{}
+	walk(doc, func(n *html.Node) bool {
+		switch {
+		case n.DataAtom == atom.Tr && hasClass(n, "athing"):
+			if listing, ok := parseListing(n, base); ok {
+				page.Listings = append(page.Listings, listing)
+			}
+			return false
+		case n.DataAtom == atom.A && hasClass(n, "morelink"):
+			page.Next = resolve(base, attr(n, "href"))
+			return false
+		}
+		return true
+	})
+	return page, nil
+}
+
+// Parses the title row of a story and the rows after it, up to the next story
+func parseListing(row *html.Node, base *url.URL) (Listing, bool) {
+	id, err := strconv.Atoi(attr(row, "id"))
+	if err != nil {
+		return Listing{}, false
+	}
+	// comments on item pages are athing rows too, but have no title
+	titleLine := find(row, func(n *html.Node) bool { return n.DataAtom == atom.Span && hasClass(n, "titleline") })
+	if titleLine == nil {
+		return Listing{}, false
+	}
+
+	item := &hackernews.RawItem{ID: id, ItemType: "job"}
+	listing := Listing{Item: item}
+	if rank := find(row, func(n *html.Node) bool { return hasClass(n, "rank") }); rank != nil {
+		listing.Rank, _ = strconv.Atoi(strings.TrimSuffix(text(rank), "."))
+	}
+	if link := find(titleLine, func(n *html.Node) bool { return n.DataAtom == atom.A }); link != nil {
+		item.Title = text(link)
+		// Ask HN and other text posts link to their own item page, and have no url in the api
+		if href := attr(link, "href"); !strings.HasPrefix(href, "item?id=") {
+			item.URL = resolve(base, href)
+		}
+	}
+	if site := find(titleLine, func(n *html.Node) bool { return hasClass(n, "sitestr") }); site != nil {
+		listing.Site = text(site)
+	}
+
+	for next := row.NextSibling; next != nil; next = next.NextSibling {
+		if next.Type != html.ElementNode {
+			continue
+		}
+		if hasClass(next, "athing") {
+			break
+		}
+		parseSubtext(next, &listing)
+		if top := find(next, func(n *html.Node) bool { return hasClass(n, "toptext") }); top != nil {
+			item.Text = innerHTML(top)
+		}
+	}
+	return listing, true
+}
+
+// Reads the points, author, age and comment count under a title
+func parseSubtext(row *html.Node, listing *Listing) {
+	subtext := find(row, func(n *html.Node) bool { return hasClass(n, "subtext") })
+	if subtext == nil {
+		return
+	}
+	item := listing.Item
+
+	walk(subtext, func(n *html.Node) bool {
+		switch {
+		case hasClass(n, "score"):
+			// "123 points" or "1 point"
+			if fields := strings.Fields(text(n)); len(fields) > 0 {
+				item.Score, _ = strconv.Atoi(fields[0])
+			}
+		case hasClass(n, "hnuser"):
+			item.By = text(n)
+			// jobs have no author
+			item.ItemType = "story"
+		case hasClass(n, "age"):
+			listing.Age = text(n)
+			item.Timestamp = parseAge(attr(n, "title"))
+			return false
+		case n.DataAtom == atom.A && strings.HasPrefix(attr(n, "href"), "item?id="):
+			// the comments link reads "45 comments", "1 comment" or "discuss"
+			if fields := strings.Fields(text(n)); len(fields) == 2 && strings.HasPrefix(fields[1], "comment") {
+				item.Descendants, _ = strconv.Atoi(fields[0])
+			}
+		}
+		return true
+	})
+}
+
+// Parses the title of an age, which is "2024-08-12T10:00:00 1723456800", or just the time in UTC on older pages.
+// Returns 0 if it cannot be parsed
+func parseAge(title string) int {
+	fields := strings.Fields(title)
+	if len(fields) == 0 {
+		return 0
+	}
+	if len(fields) > 1 {
+		if unix, err := strconv.Atoi(fields[1]); err == nil {
+			return unix
+		}
+	}
+	posted, err := time.Parse("2006-01-02T15:04:05", fields[0])
+	if err != nil {
+		return 0
+	}
+	return int(posted.Unix())
+}
+
+// Calls visit on n and its descendants in document order, skipping the children of nodes where visit returns false
+func walk(n *html.Node, visit func(*html.Node) bool) {
+	if n.Type == html.ElementNode && !visit(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, visit)
+	}
+}
+
+// Returns the first element under n, or n itself, that matches
+func find(n *html.Node, match func(*html.Node) bool) *html.Node {
+	var found *html.Node
+	walk(n, func(c *html.Node) bool {
+		if found == nil && match(c) {
+			found = c
+		}
+		return found == nil
+	})
+	return found
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the text of n with whitespace collapsed
+func text(n *html.Node) string {
+	var b strings.Builder
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// Returns the html of the children of n, as the api returns the text of posts
+func innerHTML(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		html.Render(&b, c)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func resolve(base *url.URL, href string) string {
+	ref, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return base.ResolveReference(ref).String()
+}
@@ -0,0 +1,93 @@
+package scraper
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"testing"
+
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/google/go-cmp/cmp"
+)
+
+func helperLoadBytes(t *testing.T, name string) []byte {
+	bytes, err := ioutil.ReadFile(filepath.Join("./testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes
+}
+
+func TestParsePage(t *testing.T) {
+	log.Println("Testing parsing list pages")
+
+	page, err := ParsePage(bytes.NewReader(helperLoadBytes(t, "news.html")), "https://news.ycombinator.com/news")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Listing{
+		{1, &hackernews.RawItem{ID: 41234567, ItemType: "story", By: "rsc", Timestamp: 1723456800, URL: "https://github.com/golang/go/issues/12345",
+			Score: 512, Title: "Go & the <generic> future", Descendants: 231}, "github.com/golang", "3 hours ago"},
+		// ask posts have no url, and older pages give the time without the unix timestamp
+		{2, &hackernews.RawItem{ID: 41234600, ItemType: "story", By: "whoishiring", Timestamp: 1723465800,
+			Score: 3, Title: "Ask HN: What are you working on?"}, "", "30 minutes ago"},
+		// jobs have no points, author or comments
+		{3, &hackernews.RawItem{ID: 41234700, ItemType: "job", Timestamp: 1723453200, URL: "https://www.ycombinator.com/companies/example/jobs/abc",
+			Title: "Example (YC S21) Is Hiring Go Engineers"}, "ycombinator.com", "4 hours ago"},
+		{4, &hackernews.RawItem{ID: 41234800, ItemType: "story", By: "pg", Timestamp: 1723467600, URL: "http://example.com/posts/1",
+			Score: 1, Title: "A small post", Descendants: 1}, "example.com", "1 minute ago"},
+	}
+	if !cmp.Equal(page.Listings, expected) {
+		t.Errorf("Listings incorrect. \n%s", cmp.Diff(expected, page.Listings))
+	}
+	if page.Next != "https://news.ycombinator.com/news?p=2" {
+		t.Errorf("Next page incorrect. Expected %s Actual %s", "https://news.ycombinator.com/news?p=2", page.Next)
+	}
+
+	page, err = ParsePage(bytes.NewReader(helperLoadBytes(t, "news_2.html")), "https://news.ycombinator.com/news?p=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Listings) != 2 || page.Listings[1].Rank != 6 || page.Next != "" {
+		t.Errorf("Last page incorrect. %d listings, next %q", len(page.Listings), page.Next)
+	}
+}
+
+func TestParseItemPage(t *testing.T) {
+	log.Println("Testing parsing item pages")
+
+	page, err := ParsePage(bytes.NewReader(helperLoadBytes(t, "item.html")), "https://news.ycombinator.com/item?id=41234600")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// comments are not listed
+	expected := []Listing{
+		{0, &hackernews.RawItem{ID: 41234600, ItemType: "story", By: "whoishiring", Timestamp: 1723465800, Score: 5,
+			Title: "Ask HN: What are you working on?", Descendants: 2, Text: "Share what you&#39;re building.<p>Side projects welcome.</p>"}, "", "1 hour ago"},
+	}
+	if !cmp.Equal(page.Listings, expected) {
+		t.Errorf("Listings incorrect. \n%s", cmp.Diff(expected, page.Listings))
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	log.Println("Testing parsing ages")
+
+	tests := []struct {
+		title    string
+		expected int
+	}{
+		{"2024-08-12T10:00:00 1723456800", 1723456800},
+		{"2024-08-12T10:00:00", 1723456800},
+		{"yesterday", 0},
+		{"", 0},
+	}
+	for _, test := range tests {
+		if actual := parseAge(test.title); actual != test.expected {
+			t.Errorf("parseAge(%q) Expected %d Actual %d", test.title, test.expected, actual)
+		}
+	}
+}
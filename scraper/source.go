@@ -0,0 +1,247 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+const (
+	SITE_URL   = "https://news.ycombinator.com"
+	USER_AGENT = "hn-scraper (+https://github.com/alis93/hn-scraper)"
+	// Pages larger than this are cut off
+	MAX_PAGE_BYTES = 2 << 20
+	// Wait between requests, as the site rate limits scrapers
+	DEFAULT_PAGE_DELAY = time.Second
+)
+
+// The page of the site listing each story list
+var listPages = map[string]string{
+	"top":  "news",
+	"new":  "newest",
+	"best": "best",
+	"ask":  "ask",
+	"show": "show",
+	"job":  "jobs",
+}
+
+// Reads stories from the html pages of news.ycombinator.com instead of the api.
+// Items listed by ListIDs are kept so Item does not request them again.
+// Only stories and jobs can be read, and they have no kids or parts
+type Source struct {
+	http      *http.Client
+	siteURL   string
+	retry     hackernews.RetryPolicy
+	userAgent string
+	pageDelay time.Duration
+	logger    *slog.Logger
+	metrics   *hackernews.ClientMetrics
+
+	mu          sync.Mutex
+	items       map[int]*hackernews.RawItem
+	lastRequest time.Time
+}
+
+var _ hackernews.Source = &Source{}
+
+// Optional settings applied when creating a source
+type SourceOption func(*Source)
+
+// Sets the url of the site, for example to point the source at a test server.
+func WithSiteURL(siteURL string) SourceOption {
+	return func(s *Source) {
+		s.siteURL = siteURL
+	}
+}
+
+// Sets how failed requests are retried. By default requests are not retried.
+func WithRetries(policy hackernews.RetryPolicy) SourceOption {
+	return func(s *Source) {
+		s.retry = policy
+	}
+}
+
+// Sends requests with rt instead of http.DefaultTransport, for example to record or replay them.
+func WithTransport(rt http.RoundTripper) SourceOption {
+	return func(s *Source) {
+		s.http.Transport = rt
+	}
+}
+
+// Sets the User-Agent header sent with each request.
+func WithUserAgent(userAgent string) SourceOption {
+	return func(s *Source) {
+		s.userAgent = userAgent
+	}
+}
+
+// Sets the least time between requests. Defaults to DEFAULT_PAGE_DELAY.
+func WithPageDelay(delay time.Duration) SourceOption {
+	return func(s *Source) {
+		s.pageDelay = delay
+	}
+}
+
+// Logs requests at debug level and retries at warn level to logger.
+// By default nothing is logged.
+func WithLogger(logger *slog.Logger) SourceOption {
+	return func(s *Source) {
+		s.logger = logger
+	}
+}
+
+// Records page requests, latencies and retries in m, as the api client does.
+func WithMetrics(m *hackernews.ClientMetrics) SourceOption {
+	return func(s *Source) {
+		s.metrics = m
+	}
+}
+
+// Creates a source with given timeout in seconds
+func NewSource(timeout int, opts ...SourceOption) (*Source, error) {
+	if timeout <= 0 {
+		return nil, InvalidTimeOutErr
+	}
+
+	source := &Source{
+		http:      &http.Client{Timeout: time.Duration(timeout) * time.Second},
+		siteURL:   SITE_URL,
+		userAgent: USER_AGENT,
+		pageDelay: DEFAULT_PAGE_DELAY,
+		logger:    slog.New(slog.DiscardHandler),
+		items:     make(map[int]*hackernews.RawItem),
+	}
+	for _, opt := range opts {
+		opt(source)
+	}
+	if source.metrics != nil {
+		source.http.Transport = source.metrics.Instrument(source.http.Transport)
+	}
+	return source, nil
+}
+
+// Returns the ids of the first n stories of list, following the "More" link until there are enough.
+// list and n are checked with hackernews.ValidateList
+func (s *Source) ListIDs(ctx context.Context, list string, n int) ([]int, error) {
+	if err := hackernews.ValidateList(list, n); err != nil {
+		return nil, err
+	}
+
+	ids := []int{}
+	seen := make(map[int]bool)
+	pageURL := fmt.Sprintf("%s/%s", s.siteURL, listPages[list])
+	for pageURL != "" && len(ids) < n {
+		page, err := s.page(ctx, pageURL)
+		if err != nil {
+			return nil, err
+		}
+		if len(page.Listings) == 0 {
+			// an empty first page is an error, but an empty later page is the end of the list
+			if len(ids) == 0 {
+				return nil, &NoStoriesErr{pageURL}
+			}
+			break
+		}
+
+		for _, listing := range page.Listings {
+			// stories move down the list between pages, so can be listed twice
+			if seen[listing.Item.ID] || len(ids) == n {
+				continue
+			}
+			seen[listing.Item.ID] = true
+			ids = append(ids, listing.Item.ID)
+			s.keep(listing.Item)
+		}
+		pageURL = page.Next
+	}
+	return ids, nil
+}
+
+// Returns the story with the id, from a list read earlier or its own page.
+// Returns *hackernews.MissingItemErr if the item is not a story or job
+func (s *Source) Item(ctx context.Context, id int) (*hackernews.RawItem, error) {
+	s.mu.Lock()
+	item, ok := s.items[id]
+	s.mu.Unlock()
+	if ok {
+		copied := *item
+		return &copied, nil
+	}
+
+	page, err := s.page(ctx, fmt.Sprintf("%s/item?id=%d", s.siteURL, id))
+	if err != nil {
+		return nil, err
+	}
+	for _, listing := range page.Listings {
+		if listing.Item.ID == id {
+			s.keep(listing.Item)
+			return listing.Item, nil
+		}
+	}
+	return nil, hackernews.NewMissingItemErr(id)
+}
+
+func (s *Source) keep(item *hackernews.RawItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *item
+	s.items[item.ID] = &copied
+}
+
+// Requests and parses the page, waiting for the page delay since the last request
+func (s *Source) page(ctx context.Context, pageURL string) (*Page, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", s.userAgent)
+
+	start := time.Now()
+	res, err := s.retry.Do(s.http, req, func(res *http.Response, err error) {
+		s.metrics.ObserveRetry(req.URL.Path)
+		if err == nil {
+			err = hackernews.NewStatusCodeErr(pageURL, res.StatusCode)
+		}
+		s.logger.Warn("retrying page request", "url", pageURL, "error", err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	s.logger.Debug("page request", "url", pageURL, "status", res.StatusCode, "duration", time.Since(start))
+
+	if res.StatusCode != http.StatusOK {
+		return nil, hackernews.NewStatusCodeErr(pageURL, res.StatusCode)
+	}
+	return ParsePage(io.LimitReader(res.Body, MAX_PAGE_BYTES), res.Request.URL.String())
+}
+
+// Waits until the page delay has passed since the previous request
+func (s *Source) wait(ctx context.Context) error {
+	s.mu.Lock()
+	next := s.lastRequest.Add(s.pageDelay)
+	now := time.Now()
+	if next.Before(now) {
+		next = now
+	}
+	s.lastRequest = next
+	s.mu.Unlock()
+
+	select {
+	case <-time.After(time.Until(next)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,222 @@
+package scraper
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/alis93/hn-scraper/metrics"
+)
+
+// Serves the fixtures as the site, counting requests
+func helperSiteServer(t *testing.T, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		switch {
+		case r.URL.Path == "/news" && r.URL.Query().Get("p") == "2":
+			w.Write(helperLoadBytes(t, "news_2.html"))
+		case r.URL.Path == "/news":
+			w.Write(helperLoadBytes(t, "news.html"))
+		case r.URL.Path == "/item" && r.URL.Query().Get("id") == "41234600":
+			w.Write(helperLoadBytes(t, "item.html"))
+		case r.URL.Path == "/item" && r.URL.Query().Get("id") == "2":
+			// a comment's page has no story listing
+			w.Write([]byte("<html><body><table></table></body></html>"))
+		case r.URL.Path == "/newest":
+			// a rate limited scraper gets an empty page
+			w.Write([]byte("<html><body>Sorry.</body></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestSourceListIDs(t *testing.T) {
+	log.Println("Testing listing stories from pages")
+
+	var requests int32
+	server := helperSiteServer(t, &requests)
+	defer server.Close()
+
+	source, err := NewSource(5, WithSiteURL(server.URL), WithPageDelay(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := source.ListIDs(context.Background(), "top", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 3 || ids[2] != 41234700 || requests != 1 {
+		t.Errorf("Expected the first 3 ids from one page but got %v from %d requests", ids, requests)
+	}
+
+	// the story repeated on the second page is only listed once, and the list ends without a more link
+	ids, err = source.ListIDs(context.Background(), "top", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []int{41234567, 41234600, 41234700, 41234800, 41234900}
+	if len(ids) != len(expected) {
+		t.Fatalf("Expected ids %v but got %v", expected, ids)
+	}
+	for i := range expected {
+		if ids[i] != expected[i] {
+			t.Errorf("Expected ids %v but got %v", expected, ids)
+			break
+		}
+	}
+
+	if _, err := source.ListIDs(context.Background(), "new", 10); err == nil {
+		t.Errorf("Expected an empty page to fail")
+	} else if _, ok := err.(*NoStoriesErr); !ok {
+		t.Errorf("Expected *NoStoriesErr but got %v", err)
+	}
+	if _, err := source.ListIDs(context.Background(), "recent", 10); err == nil {
+		t.Errorf("Expected unknown list to fail")
+	}
+}
+
+func TestSourceItem(t *testing.T) {
+	log.Println("Testing reading stories from pages")
+
+	var requests int32
+	server := helperSiteServer(t, &requests)
+	defer server.Close()
+
+	source, err := NewSource(5, WithSiteURL(server.URL), WithPageDelay(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.ListIDs(context.Background(), "top", 4); err != nil {
+		t.Fatal(err)
+	}
+
+	// listed stories are not requested again
+	item, err := source.Item(context.Background(), 41234567)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Title != "Go & the <generic> future" || requests != 1 {
+		t.Errorf("Expected listed story without a request but got %q after %d requests", item.Title, requests)
+	}
+
+	// stories that were not listed are read from their own page
+	source, _ = NewSource(5, WithSiteURL(server.URL), WithPageDelay(0))
+	item, err = source.Item(context.Background(), 41234600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Score != 5 || item.Text == "" {
+		t.Errorf("Item page story incorrect. %+v", item)
+	}
+
+	if _, err := source.Item(context.Background(), 1); err == nil {
+		t.Errorf("Expected missing item to fail")
+	} else if _, ok := err.(*hackernews.StatusCodeErr); !ok || hackernews.ErrorKind(err) != "status_code" {
+		t.Errorf("Expected *hackernews.StatusCodeErr but got %v", err)
+	}
+	if _, err := source.Item(context.Background(), 2); err == nil {
+		t.Errorf("Expected item that is not a story to fail")
+	} else if _, ok := err.(*hackernews.MissingItemErr); !ok || hackernews.ErrorKind(err) != "missing_item" {
+		t.Errorf("Expected *hackernews.MissingItemErr but got %v", err)
+	}
+}
+
+func TestSourceConvert(t *testing.T) {
+	log.Println("Testing converting scraped stories")
+
+	var requests int32
+	server := helperSiteServer(t, &requests)
+	defer server.Close()
+
+	source, _ := NewSource(5, WithSiteURL(server.URL), WithPageDelay(0))
+	converter, err := hackernews.NewItemConverter(hackernews.DefaultConverterConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids, _ := source.ListIDs(context.Background(), "top", 4)
+	item, _ := source.Item(context.Background(), ids[0])
+	story, err := converter.Convert(1, item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if story.Domain != "github.com" || story.Points != 512 || story.Comments != 231 || story.Author != "rsc" {
+		t.Errorf("Converted story incorrect. %s", story)
+	}
+}
+
+func TestSourcePageDelay(t *testing.T) {
+	log.Println("Testing waiting between pages")
+
+	var requests int32
+	server := helperSiteServer(t, &requests)
+	defer server.Close()
+
+	source, _ := NewSource(5, WithSiteURL(server.URL), WithPageDelay(50*time.Millisecond))
+	start := time.Now()
+	if _, err := source.ListIDs(context.Background(), "top", 5); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected the second page to wait for the delay but took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := source.ListIDs(ctx, "top", 5); err != context.Canceled {
+		t.Errorf("Expected context.Canceled but got %v", err)
+	}
+}
+
+func TestNewSourceInvalidTimeout(t *testing.T) {
+	if _, err := NewSource(0); err != InvalidTimeOutErr {
+		t.Errorf("Expected InvalidTimeOutErr but got %v", err)
+	}
+}
+
+func TestSourceMetrics(t *testing.T) {
+	log.Println("Testing page requests are recorded in client metrics")
+
+	// the first request for the news page fails once
+	var requests, failed int32
+	site := helperSiteServer(t, &requests)
+	defer site.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/news" && atomic.AddInt32(&failed, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		site.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	registry := metrics.NewRegistry()
+	source, err := NewSource(5, WithSiteURL(server.URL), WithPageDelay(0), WithMetrics(hackernews.NewClientMetrics(registry)),
+		WithRetries(hackernews.RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.ListIDs(context.Background(), "top", 3); err != nil {
+		t.Fatal(err)
+	}
+
+	var text strings.Builder
+	registry.WriteText(&text)
+	for _, line := range []string{
+		`hn_client_requests_total{endpoint="news",status="503"} 1`,
+		`hn_client_requests_total{endpoint="news",status="200"} 1`,
+		`hn_client_retries_total{endpoint="news"} 1`,
+	} {
+		if !strings.Contains(text.String(), line) {
+			t.Errorf("Expected metrics to include %s. \n%s", line, text.String())
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alis93/hn-scraper/index"
+)
+
+// Searches an index built by the index command. Works offline.
+func defineSearch(flags *flag.FlagSet) func(args []string) {
+	path := flags.String("index", DEFAULT_INDEX, "Index file written by the index command")
+	limit := flags.Int("limit", 10, "How many results to show")
+	format := flags.String("format", "text", "Output format. Either text or json")
+	logs := addLogFlags(flags)
+	return func(args []string) {
+		logs.setup()
+
+		if *format != "text" && *format != "json" {
+			fatal("invalid --format, must be text or json", "value", *format)
+		}
+		query := strings.Join(args, " ")
+		if query == "" {
+			fatal("a query is required, for example hn-scraper search \"rust compiler\"")
+		}
+
+		idx, err := index.Load(*path)
+		if err != nil {
+			fatalErr(err)
+		}
+		hits, err := idx.Search(query, *limit)
+		if err != nil {
+			fatalErr(err)
+		}
+
+		if *format == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "    ")
+			encoder.Encode(hits)
+			return
+		}
+
+		if len(hits) == 0 {
+			fmt.Fprintln(os.Stdout, "No results")
+			return
+		}
+		for i, hit := range hits {
+			title := hit.Title
+			if hit.Type == "comment" {
+				title = fmt.Sprintf("Comment on story %d", hit.StoryID)
+			}
+			fmt.Fprintf(os.Stdout, "%d. %s (%.2f)\n", i+1, title, hit.Score)
+			fmt.Fprintf(os.Stdout, "   %s by %s https://news.ycombinator.com/item?id=%d\n", hit.Type, hit.Author, hit.ID)
+			if hit.URL != "" {
+				fmt.Fprintf(os.Stdout, "   %s\n", hit.URL)
+			}
+			if hit.Snippet != "" {
+				fmt.Fprintf(os.Stdout, "   %s\n", hit.Snippet)
+			}
+		}
+	}
+}
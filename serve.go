@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/alis93/hn-scraper/metrics"
+	"github.com/alis93/hn-scraper/server"
+)
+
+// Serves scraped stories as a JSON api, refreshing them in the background.
+func defineServe(flags *flag.FlagSet) func(args []string) {
+	addr := flags.String("addr", ":8080", "Address to listen on")
+	lists := flags.String("lists", "top", "Comma separated story lists to serve. The first is the default")
+	size := flags.Int("size", 100, "How many stories of each list to keep")
+	interval := flags.Duration("interval", 5*time.Minute, "How often to refresh the stories")
+	dedupe := flags.Bool("dedupe", false, "Group submissions of the same article, keeping the highest ranked")
+	logs := addLogFlags(flags)
+	settings := addConfigFlags(flags)
+	sources := addSourceFlags(flags)
+	return func(args []string) {
+		logs.setup()
+		cfg := settings.load()
+
+		registry := metrics.NewRegistry()
+
+		source := sources.openWithMetrics(cfg, hackernews.NewClientMetrics(registry))
+		converter, err := newConverter(cfg.Converter,
+			hackernews.WithConversionMetrics(hackernews.NewConverterMetrics(registry)))
+		if err != nil {
+			fatalErr(err)
+		}
+
+		s, err := server.NewServer(source, converter, strings.Split(*lists, ","), *size, *interval,
+			server.WithLogger(Logger), server.WithDedupe(*dedupe))
+		if err != nil {
+			fatalErr(err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		go s.Run(ctx)
+
+		mux := http.NewServeMux()
+		mux.Handle("/", s.Handler())
+		mux.Handle("/metrics", registry.Handler())
+
+		httpServer := &http.Server{Addr: *addr, Handler: mux}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			httpServer.Shutdown(shutdownCtx)
+		}()
+
+		Logger.Info("serving stories", "addr", *addr, "lists", *lists)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fatalErr(err)
+		}
+		sources.close()
+	}
+}
@@ -0,0 +1,10 @@
+package server
+
+import "fmt"
+
+var (
+	NoSourceErr        = fmt.Errorf("Server needs a source to read stories from")
+	NoConverterErr     = fmt.Errorf("Server needs a converter to turn items into stories")
+	NoListsErr         = fmt.Errorf("At least one story list must be served")
+	InvalidIntervalErr = fmt.Errorf("Refresh interval must be more than 0")
+)
@@ -0,0 +1,326 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/alis93/hn-scraper/pipeline"
+)
+
+// Serves stories scraped from hackernews as a JSON api.
+// Each configured list is refreshed in the background so clients never wait on the hackernews api.
+type Server struct {
+	source    hackernews.Source
+	converter *hackernews.ItemConverter
+	lists     []string
+	size      int
+	interval  time.Duration
+	logger    *slog.Logger
+	dedupe    bool
+
+	mu    sync.RWMutex
+	cache map[string]*listCache
+}
+
+// The latest stories of a single list
+type listCache struct {
+	Stories []*hackernews.Story `json:"stories"`
+	Updated time.Time           `json:"updated"`
+	Err     string              `json:"error,omitempty"`
+}
+
+// Body of the /stories response
+type storiesResponse struct {
+	List    string              `json:"list"`
+	Updated time.Time           `json:"updated"`
+	Stories []*hackernews.Story `json:"stories"`
+}
+
+// Body of the /healthz response
+type healthResponse struct {
+	Status string                `json:"status"`
+	Lists  map[string]listHealth `json:"lists"`
+}
+
+type listHealth struct {
+	Updated time.Time `json:"updated"`
+	Stories int       `json:"stories"`
+	Err     string    `json:"error,omitempty"`
+}
+
+// Body of error responses
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Optional settings applied when creating a server
+type Option func(*Server)
+
+// Logs failed refreshes and stories to logger. By default nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// Groups duplicate stories with hackernews.Dedupe before they are cached.
+func WithDedupe(dedupe bool) Option {
+	return func(s *Server) {
+		s.dedupe = dedupe
+	}
+}
+
+// Creates a server keeping the first size stories of each list from source, refreshed every interval.
+func NewServer(source hackernews.Source, converter *hackernews.ItemConverter, lists []string, size int, interval time.Duration, opts ...Option) (*Server, error) {
+	if source == nil {
+		return nil, NoSourceErr
+	}
+	if converter == nil {
+		return nil, NoConverterErr
+	}
+	if len(lists) == 0 {
+		return nil, NoListsErr
+	}
+	for _, list := range lists {
+		if err := hackernews.ValidateList(list, size); err != nil {
+			return nil, err
+		}
+	}
+	if interval <= 0 {
+		return nil, InvalidIntervalErr
+	}
+
+	s := &Server{
+		source:    source,
+		converter: converter,
+		lists:     lists,
+		size:      size,
+		interval:  interval,
+		logger:    slog.New(slog.DiscardHandler),
+		cache:     make(map[string]*listCache),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Refreshes every list straight away and then once per interval until ctx is cancelled.
+func (s *Server) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.Refresh(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Fetches every list from hackernews and replaces the cached stories.
+// If a list fails to refresh the previous stories are kept and the error is reported on /healthz.
+// Stops without changing the remaining lists once ctx is cancelled
+func (s *Server) Refresh(ctx context.Context) {
+	for _, list := range s.lists {
+		stories, err := s.fetchList(ctx, list)
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.mu.Lock()
+		cached, ok := s.cache[list]
+		if !ok {
+			cached = &listCache{}
+			s.cache[list] = cached
+		}
+		if err != nil {
+			s.logger.Error("unable to refresh stories", "list", list, "error", err, "error_kind", hackernews.ErrorKind(err))
+			cached.Err = err.Error()
+		} else {
+			cached.Stories = stories
+			cached.Updated = time.Now()
+			cached.Err = ""
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Retrieves and converts the stories of a list, in rank order.
+// Stories that fail to be retrieved or converted are logged and left out. Returns ctx.Err() if ctx is cancelled
+func (s *Server) fetchList(ctx context.Context, list string) ([]*hackernews.Story, error) {
+	stories := []*hackernews.Story{}
+	p, err := pipeline.NewPipeline(s.source, s.converter,
+		pipeline.WithPolicy(pipeline.STAGE_FETCH, pipeline.POLICY_DROP),
+		pipeline.WithPolicy(pipeline.STAGE_CONVERT, pipeline.POLICY_DROP),
+		pipeline.WithSort(pipeline.ByRank),
+		pipeline.WithSink(func(result hackernews.Result) error {
+			if !result.Failed() {
+				stories = append(stories, result.Story)
+			}
+			return nil
+		}),
+		pipeline.WithLogger(s.logger.With("list", list)))
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Run(ctx, list, s.size); err != nil {
+		return nil, err
+	}
+
+	if s.dedupe {
+		stories = hackernews.Dedupe(stories, hackernews.DUPLICATE_TITLE_SIMILARITY)
+	}
+	return stories, nil
+}
+
+// Returns the handler serving /stories, /stories/{id} and /healthz
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stories", s.handleStories)
+	mux.HandleFunc("/stories/", s.handleStory)
+	mux.HandleFunc("/healthz", s.handleHealth)
+	return mux
+}
+
+// GET /stories?list=top&limit=10&filter=go
+// filter is matched case insensitively against the title, author and url
+func (s *Server) handleStories(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	list := query.Get("list")
+	if list == "" {
+		list = s.lists[0]
+	}
+
+	limit := s.size
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive number")
+			return
+		}
+		limit = parsed
+	}
+
+	filter := strings.ToLower(query.Get("filter"))
+
+	if !s.serves(list) {
+		writeError(w, http.StatusNotFound, list+" stories are not served")
+		return
+	}
+
+	s.mu.RLock()
+	cached, ok := s.cache[list]
+	if !ok {
+		s.mu.RUnlock()
+		writeError(w, http.StatusServiceUnavailable, list+" stories have not been loaded yet")
+		return
+	}
+
+	res := storiesResponse{List: list, Updated: cached.Updated, Stories: []*hackernews.Story{}}
+	for _, story := range cached.Stories {
+		if len(res.Stories) >= limit {
+			break
+		}
+		if matchesFilter(story, filter) {
+			res.Stories = append(res.Stories, story)
+		}
+	}
+	s.mu.RUnlock()
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// GET /stories/{id}
+// Only stories in one of the served lists can be found
+func (s *Server) handleStory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/stories/"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "story id must be a number")
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, list := range s.lists {
+		cached, ok := s.cache[list]
+		if !ok {
+			continue
+		}
+		for _, story := range cached.Stories {
+			if story.ID == id {
+				writeJSON(w, http.StatusOK, story)
+				return
+			}
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "story "+strconv.Itoa(id)+" not found")
+}
+
+// GET /healthz
+// Healthy once every list has been refreshed and none are older than three intervals
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	res := healthResponse{Status: "ok", Lists: make(map[string]listHealth)}
+
+	s.mu.RLock()
+	for _, list := range s.lists {
+		cached, ok := s.cache[list]
+		if !ok {
+			res.Status = "starting"
+			continue
+		}
+		res.Lists[list] = listHealth{cached.Updated, len(cached.Stories), cached.Err}
+		if time.Since(cached.Updated) > 3*s.interval {
+			res.Status = "stale"
+		}
+	}
+	s.mu.RUnlock()
+
+	status := http.StatusOK
+	if res.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, res)
+}
+
+// Returns true if list is one of the lists kept up to date
+func (s *Server) serves(list string) bool {
+	for _, served := range s.lists {
+		if served == list {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if the title, author or url contains filter.
+// filter must already be lowercase
+func matchesFilter(story *hackernews.Story, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(story.Title), filter) ||
+		strings.Contains(strings.ToLower(story.Author), filter) ||
+		strings.Contains(strings.ToLower(story.URL), filter)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorResponse{msg})
+}
@@ -0,0 +1,250 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+// Starts a fake hackernews api serving the fixtures in the hackernews testdata folder
+func mockAPIHelper(t *testing.T) *httptest.Server {
+	testdata := filepath.Join("..", "hackernews", "testdata")
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		name = strings.Replace(name, "item/", "item_", 1)
+		if name == "newstories.json" {
+			name = "topstories.json"
+		}
+		body, err := ioutil.ReadFile(filepath.Join(testdata, name))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+}
+
+// Returns a client of the fake api and a converter with the default config
+func helperSource(t *testing.T, api *httptest.Server) (*hackernews.Client, *hackernews.ItemConverter) {
+	client, err := hackernews.NewClient(5, hackernews.WithAPIURL(api.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	converter, err := hackernews.NewItemConverter(hackernews.DefaultConverterConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, converter
+}
+
+func newTestServer(t *testing.T, lists []string) (*Server, *httptest.Server) {
+	api := mockAPIHelper(t)
+	client, converter := helperSource(t, api)
+	s, err := NewServer(client, converter, lists, 10, time.Minute, WithLogger(slog.New(slog.DiscardHandler)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, api
+}
+
+// Sends a get request to the handler and decodes the json response into v
+func get(t *testing.T, handler http.Handler, path string, v interface{}) int {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("Response to %s was not json. \n Reason : %s", path, err.Error())
+		}
+	}
+	return rec.Code
+}
+
+func TestNewServer(t *testing.T) {
+	log.Println("Testing server creation")
+	api := mockAPIHelper(t)
+	defer api.Close()
+	client, converter := helperSource(t, api)
+
+	if _, err := NewServer(nil, converter, []string{"top"}, 10, time.Minute); err != NoSourceErr {
+		t.Errorf("Expected error was incorrect. \n\t Expected %v got %v", NoSourceErr, err)
+	}
+	if _, err := NewServer(client, nil, []string{"top"}, 10, time.Minute); err != NoConverterErr {
+		t.Errorf("Expected error was incorrect. \n\t Expected %v got %v", NoConverterErr, err)
+	}
+	if _, err := NewServer(client, converter, nil, 10, time.Minute); err != NoListsErr {
+		t.Errorf("Expected error was incorrect. \n\t Expected %v got %v", NoListsErr, err)
+	}
+	if _, err := NewServer(client, converter, []string{"worst"}, 10, time.Minute); err == nil {
+		t.Errorf("Expected an error for an unknown list")
+	}
+	if _, err := NewServer(client, converter, []string{"ask"}, 300, time.Minute); err != hackernews.OutOfRangeErr {
+		t.Errorf("Expected error was incorrect. \n\t Expected %v got %v", hackernews.OutOfRangeErr, err)
+	}
+	if _, err := NewServer(client, converter, []string{"top"}, 10, 0); err != InvalidIntervalErr {
+		t.Errorf("Expected error was incorrect. \n\t Expected %v got %v", InvalidIntervalErr, err)
+	}
+	if s, err := NewServer(client, converter, []string{"top"}, 10, time.Minute); err != nil || s.logger == nil {
+		t.Errorf("Expected a server logging nothing by default, got %v", err)
+	}
+	if s, err := NewServer(client, converter, []string{"top"}, 10, time.Minute, WithDedupe(true)); err != nil || !s.dedupe {
+		t.Errorf("Expected a server grouping duplicates, got %v", err)
+	}
+}
+
+func TestRefreshCancelled(t *testing.T) {
+	log.Println("Testing a cancelled refresh leaves the cache alone")
+	s, api := newTestServer(t, []string{"top"})
+	defer api.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Refresh(ctx)
+
+	health := healthResponse{}
+	if code := get(t, s.Handler(), "/healthz", &health); code != http.StatusServiceUnavailable || health.Status != "starting" {
+		t.Errorf("Expected server to still be starting after a cancelled refresh. Got %d %+v", code, health)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	log.Println("Testing server health endpoint")
+	s, api := newTestServer(t, []string{"top"})
+	defer api.Close()
+	handler := s.Handler()
+
+	health := healthResponse{}
+	if code := get(t, handler, "/healthz", &health); code != http.StatusServiceUnavailable || health.Status != "starting" {
+		t.Errorf("Expected server to be starting before the first refresh. Got %d %+v", code, health)
+	}
+
+	s.Refresh(context.Background())
+	if code := get(t, handler, "/healthz", &health); code != http.StatusOK || health.Status != "ok" {
+		t.Errorf("Expected server to be healthy after refresh. Got %d %+v", code, health)
+	}
+}
+
+func TestStories(t *testing.T) {
+	log.Println("Testing server stories endpoint")
+	s, api := newTestServer(t, []string{"top", "new"})
+	defer api.Close()
+	handler := s.Handler()
+
+	if code := get(t, handler, "/stories", nil); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected %d before the first refresh but got %d", http.StatusServiceUnavailable, code)
+	}
+
+	s.Refresh(context.Background())
+
+	res := storiesResponse{}
+	if code := get(t, handler, "/stories", &res); code != http.StatusOK {
+		t.Fatalf("Expected %d but got %d", http.StatusOK, code)
+	}
+	// the ask hn fixture has no url and another has a misspelt scheme so are not converted
+	if res.List != "top" || len(res.Stories) != 3 {
+		t.Fatalf("Expected 3 top stories but got %d from %s", len(res.Stories), res.List)
+	}
+	for i, story := range res.Stories {
+		if i > 0 && story.Rank <= res.Stories[i-1].Rank {
+			t.Errorf("Stories are not in rank order. %d came after %d", story.Rank, res.Stories[i-1].Rank)
+		}
+	}
+
+	queryTests := []struct {
+		path     string
+		code     int
+		expected int
+	}{
+		{"/stories?limit=2", http.StatusOK, 2},
+		{"/stories?list=new&limit=3", http.StatusOK, 3},
+		{"/stories?filter=ROBOTS.TXT", http.StatusOK, 1},
+		{"/stories?filter=dankohn1", http.StatusOK, 1},
+		{"/stories?filter=nothing+matches+this", http.StatusOK, 0},
+		{"/stories?limit=0", http.StatusBadRequest, 0},
+		{"/stories?limit=abc", http.StatusBadRequest, 0},
+		{"/stories?list=best", http.StatusNotFound, 0},
+	}
+	for _, test := range queryTests {
+		t.Run(test.path, func(t *testing.T) {
+			res := storiesResponse{}
+			code := get(t, handler, test.path, &res)
+			if code != test.code {
+				t.Fatalf("Status code incorrect. \n\t Expected %d Actual %d", test.code, code)
+			}
+			if len(res.Stories) != test.expected {
+				t.Errorf("Number of stories incorrect. \n\t Expected %d Actual %d", test.expected, len(res.Stories))
+			}
+		})
+	}
+}
+
+func TestStory(t *testing.T) {
+	log.Println("Testing server single story endpoint")
+	s, api := newTestServer(t, []string{"top"})
+	defer api.Close()
+	handler := s.Handler()
+	s.Refresh(context.Background())
+
+	story := hackernews.Story{}
+	if code := get(t, handler, "/stories/20325395", &story); code != http.StatusOK {
+		t.Fatalf("Expected %d but got %d", http.StatusOK, code)
+	}
+	if story.ID != 20325395 || story.Author != "dankohn1" || story.Rank != 1 {
+		t.Errorf("Wrong story returned. Got %+v", story)
+	}
+
+	if code := get(t, handler, "/stories/1", nil); code != http.StatusNotFound {
+		t.Errorf("Expected %d but got %d", http.StatusNotFound, code)
+	}
+	if code := get(t, handler, "/stories/abc", nil); code != http.StatusBadRequest {
+		t.Errorf("Expected %d but got %d", http.StatusBadRequest, code)
+	}
+}
+
+// A source whose items never arrive, like an upstream that stopped responding
+type stuckSource struct{}
+
+func (stuckSource) ListIDs(ctx context.Context, list string, n int) ([]int, error) {
+	return []int{1, 2, 3}, nil
+}
+
+func (stuckSource) Item(ctx context.Context, id int) (*hackernews.RawItem, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestRefreshStuckUpstream(t *testing.T) {
+	log.Println("Testing shutting down during a refresh")
+	converter, err := hackernews.NewItemConverter(hackernews.DefaultConverterConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewServer(stuckSource{}, converter, []string{"top"}, 3, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the server to stop while its refresh was waiting on the upstream")
+	}
+}
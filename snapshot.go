@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/alis93/hn-scraper/offline"
+)
+
+// Saves story lists and their items to a directory that --source snapshot:DIR reads back.
+func defineSnapshot(flags *flag.FlagSet) func(args []string) {
+	lists := flags.String("lists", "top", "Comma separated story lists to save")
+	n := flags.Int("n", 30, "How many stories of each list to save")
+	out := flags.String("out", "", "Directory to save the snapshot in")
+	logs := addLogFlags(flags)
+	settings := addConfigFlags(flags)
+	sources := addSourceFlags(flags)
+	return func(args []string) {
+		logs.setup()
+		cfg := settings.load()
+
+		if *out == "" {
+			fatal("--out is required")
+		}
+		saved := strings.Split(*lists, ",")
+		for _, list := range saved {
+			if err := hackernews.ValidateList(list, *n); err != nil {
+				fatalErr(err)
+			}
+		}
+
+		source := sources.open(cfg)
+		snapshot, err := offline.SaveSnapshot(context.Background(), source, *out, saved, *n)
+		if err != nil {
+			fatalErr(err)
+		}
+		sources.close()
+
+		for _, list := range saved {
+			fmt.Fprintf(os.Stdout, "Saved %d %s stories to %s\n", len(snapshot.Lists[list]), list, *out)
+		}
+	}
+}
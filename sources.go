@@ -0,0 +1,120 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	"strings"
+
+	"github.com/alis93/hn-scraper/config"
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/alis93/hn-scraper/offline"
+	"github.com/alis93/hn-scraper/scraper"
+)
+
+// The flags choosing where a command reads stories from
+type sourceFlags struct {
+	name   *string
+	record *string
+	replay *string
+
+	// the archive being recorded, saved by close
+	archive *offline.Archive
+}
+
+// Adds --source, --record and --replay to flags
+func addSourceFlags(flags *flag.FlagSet) *sourceFlags {
+	return &sourceFlags{
+		name: flags.String("source", "api", "Where stories are read from. api for the hackernews api, html to scrape news.ycombinator.com, "+
+			"dir:PATH for a directory of api responses or snapshot:PATH for a directory saved by the snapshot command"),
+		record: flags.String("record", "", "Save every api or html response to this archive, to replay later with --replay"),
+		replay: flags.String("replay", "", "Answer api or html requests from this archive instead of the network"),
+	}
+}
+
+// Creates the source named by --source with the client settings of cfg.
+// Exits if the source cannot be opened
+func (s *sourceFlags) open(cfg *config.Config) hackernews.Source {
+	return s.openWithMetrics(cfg, nil)
+}
+
+// Creates the source named by --source like open, recording the requests of the api and html sources in m
+func (s *sourceFlags) openWithMetrics(cfg *config.Config, m *hackernews.ClientMetrics) hackernews.Source {
+	kind, path, _ := strings.Cut(*s.name, ":")
+	if (kind == "dir" || kind == "snapshot") && (*s.record != "" || *s.replay != "") {
+		fatal("--record and --replay only apply to the api and html sources", "source", *s.name)
+	}
+
+	switch kind {
+	case "api":
+		opts := []hackernews.ClientOption{}
+		if m != nil {
+			opts = append(opts, hackernews.WithMetrics(m))
+		}
+		if transport := s.transport(); transport != nil {
+			opts = append(opts, hackernews.WithTransport(transport))
+		}
+		client, err := newClient(cfg.Client, opts...)
+		if err != nil {
+			fatalErr(err)
+		}
+		return client
+	case "html":
+		scraperOpts := []scraper.SourceOption{scraper.WithRetries(cfg.Client.RetryPolicy()), scraper.WithLogger(Logger)}
+		if transport := s.transport(); transport != nil {
+			scraperOpts = append(scraperOpts, scraper.WithTransport(transport))
+		}
+		if m != nil {
+			scraperOpts = append(scraperOpts, scraper.WithMetrics(m))
+		}
+		source, err := scraper.NewSource(cfg.Client.Timeout, scraperOpts...)
+		if err != nil {
+			fatalErr(err)
+		}
+		return source
+	case "dir":
+		source, err := offline.NewDirSource(path)
+		if err != nil {
+			fatalErr(err)
+		}
+		return source
+	case "snapshot":
+		snapshot, err := offline.LoadSnapshot(path)
+		if err != nil {
+			fatalErr(err)
+		}
+		return snapshot
+	}
+	fatal("unknown source", "source", *s.name)
+	return nil
+}
+
+// Returns the transport for --record or --replay, or nil to use the network
+func (s *sourceFlags) transport() http.RoundTripper {
+	if *s.record != "" && *s.replay != "" {
+		fatal("--record and --replay cannot be used together")
+	}
+	if *s.replay != "" {
+		archive, err := offline.LoadArchive(*s.replay)
+		if err != nil {
+			fatalErr(err)
+		}
+		return archive.Replayer()
+	}
+	if *s.record != "" {
+		s.archive = offline.NewArchive()
+		return s.archive.Recorder(nil)
+	}
+	return nil
+}
+
+// Saves the recorded archive, if recording.
+// Call once the command has finished reading stories
+func (s *sourceFlags) close() {
+	if s.archive == nil {
+		return
+	}
+	if err := s.archive.Save(*s.record); err != nil {
+		fatalErr(err)
+	}
+	Logger.Info("recorded responses", "path", *s.record, "responses", s.archive.Len())
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+
+	"github.com/alis93/hn-scraper/config"
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/alis93/hn-scraper/pipeline"
+)
+
+// Creates a client from the config, logging to Logger
+func newClient(cfg config.ClientConfig, opts ...hackernews.ClientOption) (*hackernews.Client, error) {
+	opts = append(append(cfg.Options(), hackernews.WithLogger(Logger)), opts...)
+	return hackernews.NewClient(cfg.Timeout, opts...)
+}
+
+// Creates a converter from the config, logging to Logger
+func newConverter(cfg hackernews.ConverterConfig, opts ...hackernews.ConverterOption) (*hackernews.ItemConverter, error) {
+	opts = append([]hackernews.ConverterOption{hackernews.WithConversionLogger(Logger)}, opts...)
+	return hackernews.NewItemConverter(cfg, opts...)
+}
+
+// Creates a pipeline reading from source, logging to Logger
+func newPipeline(source hackernews.Source, converter *hackernews.ItemConverter, opts ...pipeline.Option) (*pipeline.Pipeline, error) {
+	opts = append([]pipeline.Option{pipeline.WithLogger(Logger)}, opts...)
+	return pipeline.NewPipeline(source, converter, opts...)
+}
+
+// Retrieves and converts the first n stories of list from source concurrently.
+// Returns the stories in rank order. Stories that fail are left out and added to rejected, if it is not nil
+func fetchStories(source hackernews.Source, converter *hackernews.ItemConverter, list string, n int, rejected *rejections) ([]*hackernews.Story, error) {
+	stories := []*hackernews.Story{}
+	p, err := newPipeline(source, converter,
+		pipeline.WithSort(pipeline.ByRank),
+		pipeline.WithSink(func(result hackernews.Result) error {
+			if !result.Failed() {
+				stories = append(stories, result.Story)
+			} else if rejected != nil {
+				rejected.add(result.Rank, result.ID, result.Item, result.Err)
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Run(context.Background(), list, n); err != nil {
+		return nil, err
+	}
+	return stories, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alis93/hn-scraper/config"
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/alis93/hn-scraper/hntest"
+)
+
+// Returns a client and converter for a fake api serving the hackernews fixtures
+func helperFakeAPI(t *testing.T) (*hackernews.Client, *hackernews.ItemConverter, *hntest.Server) {
+	t.Setenv(hntest.RECORD_ENV, "")
+	Logger = slog.New(slog.DiscardHandler)
+
+	api := hntest.NewServer(t, "hackernews/testdata")
+	cfg, _ := config.Default("")
+	cfg.Client.APIURL = api.APIURL()
+	cfg.Client.Backoff = config.Duration(time.Millisecond)
+
+	client, err := newClient(cfg.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	converter, err := newConverter(cfg.Converter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, converter, api
+}
+
+func TestFetchStoriesFaults(t *testing.T) {
+	log.Println("Testing fetching stories with a failing api")
+
+	client, converter, api := helperFakeAPI(t)
+	// one item is gone, one always fails and one recovers after a retry
+	api.Inject("/v0/item/20325395.json", hntest.Fault{Null: true})
+	api.Inject("/v0/item/20328871.json", hntest.Fault{Status: http.StatusInternalServerError})
+	api.Inject("/v0/item/20324021.json", hntest.Fault{Reset: true, Times: 1})
+
+	rejected := &rejections{}
+	stories, err := fetchStories(client, converter, "top", 5, rejected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []int{}
+	for _, story := range stories {
+		ids = append(ids, story.ID)
+	}
+	// two of the remaining fixtures have invalid urls
+	expected := []int{20324021}
+	if len(ids) != len(expected) || ids[0] != expected[0] {
+		t.Errorf("Expected stories %v but got %v", expected, ids)
+	}
+
+	var report bytes.Buffer
+	rejected.report(&report, 5)
+	for _, want := range []string{"Rejected 4 of 5 stories", "#1 id 20325395", "missing_item", "#2 id 20328871", "status_code", "invalid_url"} {
+		if !strings.Contains(report.String(), want) {
+			t.Errorf("Expected report to contain %q. \n%s", want, report.String())
+		}
+	}
+}
+
+func TestFetchStoriesListFails(t *testing.T) {
+	log.Println("Testing fetching stories when the list fails")
+
+	client, converter, api := helperFakeAPI(t)
+	api.Inject(hntest.ROUTE_LIST, hntest.Fault{Truncate: 5})
+
+	if _, err := fetchStories(client, converter, "top", 5, nil); err == nil {
+		t.Errorf("Expected a truncated list to fail")
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/alis93/hn-scraper/pipeline"
+)
+
+// Prints the stories of a list, the top stories by default.
+// This is what runs when no command is given.
+func defineTop(flags *flag.FlagSet) func(args []string) {
+	list := flags.String("list", "top", "Story list to print. One of top, new, best, ask, show or job")
+	posts := flags.Int("posts", 30, "How many stories to print, up to the size of the list")
+	logs := addLogFlags(flags)
+	settings := addConfigFlags(flags)
+	sources := addSourceFlags(flags)
+	explain := flags.Bool("explain-rejections", false, "Report every reason each rejected story failed validation")
+	dedupe := flags.Bool("dedupe", false, "Group submissions of the same article, keeping the highest ranked")
+	ndjson := flags.Bool("ndjson", false, "Print one story per line, for saving to a file that linkcheck can read")
+	withContent := flags.Bool("with-content", false, "Fetch each story's linked article and print its text and metadata")
+	failOn := flags.String("fail-on", FAIL_ON_ALL, "Exit with an error if any, all or none of the stories fail to be retrieved or converted")
+	return func(args []string) {
+		logs.setup()
+		if err := hackernews.ValidateList(*list, *posts); err != nil {
+			if size, ok := hackernews.StoryLists[*list]; ok {
+				fatal("posts must be between 1 and the size of the list", "posts", *posts, "list", *list, "size", size)
+			}
+			fatalErr(err)
+		}
+		if !validFailOn(*failOn) {
+			fatal("invalid --fail-on, must be any, all or none", "value", *failOn)
+		}
+		cfg := settings.load()
+		if !*ndjson {
+			fmt.Fprintf(os.Stdout, "Retrieving %d posts\n", *posts)
+		}
+
+		// create the api client or scraper
+		source := sources.open(cfg)
+
+		if *explain {
+			cfg.Converter.CollectAllErrors = true
+		}
+		converter, err := newConverter(cfg.Converter)
+		if err != nil {
+			fatalErr(err)
+		}
+
+		var articles *contents
+		runSummary := &summary{}
+		rejected := &rejections{}
+		stories := []*hackernews.Story{}
+		opts := []pipeline.Option{pipeline.WithSink(func(result hackernews.Result) error {
+			runSummary.add(result)
+			if result.Failed() {
+				rejected.add(result.Rank, result.ID, result.Item, result.Err)
+				return nil
+			}
+			if *dedupe {
+				// duplicates are only known once every story has arrived
+				stories = append(stories, result.Story)
+				return nil
+			}
+			// print each story as soon as it is ready
+			articles.print(result.Story, *ndjson)
+			return nil
+		})}
+		if *dedupe {
+			opts = append(opts, pipeline.WithSort(pipeline.ByRank))
+		}
+		if *withContent {
+			fetcher, err := newFetcher(cfg.Client)
+			if err != nil {
+				fatalErr(err)
+			}
+			articles = newContents(fetcher)
+			opts = append(opts, pipeline.WithEnricher(func(ctx context.Context, story *hackernews.Story) error {
+				articles.fetch(ctx, story)
+				return nil
+			}))
+		}
+
+		// retrieve the top story ids, then retrieve and convert each item
+		p, err := newPipeline(source, converter, opts...)
+		if err != nil {
+			fatalErr(err)
+		}
+		if err := p.Run(context.Background(), *list, *posts); err != nil {
+			// save what was recorded, as a failed run is the one worth replaying
+			sources.close()
+			fatalErr(err)
+		}
+		if *dedupe {
+			for _, story := range hackernews.Dedupe(stories, hackernews.DUPLICATE_TITLE_SIMILARITY) {
+				articles.print(story, *ndjson)
+			}
+		}
+
+		sources.close()
+		runSummary.write(os.Stderr)
+		if *explain {
+			rejected.report(os.Stderr, runSummary.total)
+		}
+		if runSummary.fails(*failOn) {
+			fatal("stories failed", "failed", runSummary.failed, "total", runSummary.total, "fail_on", *failOn)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alis93/hn-scraper/hackernews"
+)
+
+// Prints a user's karma, when they joined and what they wrote about themselves.
+// Users are always read from the api, as the other sources only have stories.
+func defineUser(flags *flag.FlagSet) func(args []string) {
+	format := flags.String("format", "text", "Output format. Either text or json")
+	logs := addLogFlags(flags)
+	settings := addConfigFlags(flags)
+	return func(args []string) {
+		logs.setup()
+		cfg := settings.load()
+
+		if *format != "text" && *format != "json" {
+			fatal("invalid --format, must be text or json", "value", *format)
+		}
+		if len(args) != 1 {
+			fatal("a username is required, for example hn-scraper user pg")
+		}
+
+		client, err := newClient(cfg.Client)
+		if err != nil {
+			fatalErr(err)
+		}
+		user, err := client.User(context.Background(), args[0])
+		if err != nil {
+			fatalErr(err)
+		}
+
+		if *format == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "    ")
+			encoder.Encode(user)
+			return
+		}
+		printUser(user)
+	}
+}
+
+func printUser(user *hackernews.User) {
+	joined := time.Unix(int64(user.Created), 0).UTC().Format("2006-01-02")
+	fmt.Fprintf(os.Stdout, "%s\n", user.ID)
+	fmt.Fprintf(os.Stdout, "   %d karma, joined %s, %d submissions\n", user.Karma, joined, len(user.Submitted))
+	fmt.Fprintf(os.Stdout, "   https://news.ycombinator.com/user?id=%s\n", user.ID)
+	if about := hackernews.PlainText(user.About); about != "" {
+		fmt.Fprintf(os.Stdout, "\n   %s\n", strings.ReplaceAll(about, "\n", "\n   "))
+	}
+}
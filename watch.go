@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/alis93/hn-scraper/alerts"
+	"github.com/alis93/hn-scraper/hackernews"
+	"github.com/alis93/hn-scraper/metrics"
+)
+
+// Polls story lists and posts alerts to webhooks when stories match the rules in the config file.
+func defineWatch(flags *flag.FlagSet) func(args []string) {
+	lists := flags.String("lists", "top", "Comma separated story lists to watch")
+	size := flags.Int("size", 30, "How many stories of each list to watch. 30 is the front page")
+	interval := flags.Duration("interval", 2*time.Minute, "How often to check the lists")
+	state := flags.String("state", "", "File remembering which alerts were sent, so they are not repeated after a restart")
+	metricsAddr := flags.String("metrics-addr", "", "Address to serve /metrics on. Not served if empty")
+	logs := addLogFlags(flags)
+	settings := addConfigFlags(flags)
+	sources := addSourceFlags(flags)
+	return func(args []string) {
+		logs.setup()
+		cfg := settings.load()
+
+		registry := metrics.NewRegistry()
+
+		source := sources.openWithMetrics(cfg, hackernews.NewClientMetrics(registry))
+		converter, err := newConverter(cfg.Converter,
+			hackernews.WithConversionMetrics(hackernews.NewConverterMetrics(registry)))
+		if err != nil {
+			fatalErr(err)
+		}
+
+		watched := strings.Split(*lists, ",")
+		for _, list := range watched {
+			if err := hackernews.ValidateList(list, *size); err != nil {
+				fatalErr(err)
+			}
+		}
+
+		opts := []alerts.AlerterOption{
+			alerts.WithRetries(cfg.Client.RetryPolicy()),
+			alerts.WithMetrics(alerts.NewAlertMetrics(registry)),
+			alerts.WithLogger(Logger),
+		}
+		if *state != "" {
+			opts = append(opts, alerts.WithStateFile(*state))
+		}
+		alerter, err := alerts.NewAlerter(cfg.Alerts, opts...)
+		if err != nil {
+			fatalErr(err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if *metricsAddr != "" {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", registry.Handler())
+			metricsServer := &http.Server{Addr: *metricsAddr, Handler: mux}
+			go func() {
+				if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					fatalErr(err)
+				}
+			}()
+			go func() {
+				<-ctx.Done()
+				metricsServer.Close()
+			}()
+		}
+
+		Logger.Info("watching stories", "lists", *lists, "size", *size, "interval", *interval)
+		ticker := time.NewTicker(*interval)
+		defer ticker.Stop()
+		for {
+			for _, list := range watched {
+				stories, err := fetchStories(source, converter, list, *size, nil)
+				if err != nil {
+					Logger.Error("unable to get stories", "list", list, "error", err, "error_kind", hackernews.ErrorKind(err))
+					continue
+				}
+				// delivery failures are logged by the alerter and retried on the next check
+				alerter.Check(ctx, list, stories)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				sources.close()
+				return
+			}
+		}
+	}
+}